@@ -2,8 +2,15 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -11,23 +18,113 @@ import (
 	"github.com/aaryansingh-dev/hybrid-logical-time-go/internal/billing"
 	"github.com/aaryansingh-dev/hybrid-logical-time-go/internal/clock"
 	"github.com/aaryansingh-dev/hybrid-logical-time-go/internal/engine"
+	"github.com/aaryansingh-dev/hybrid-logical-time-go/internal/telemetry"
 )
 
 func main() {
-	// initialize the engine with a ConsoleLogger for real-time visibility
-	eng := engine.NewEngine(&engine.ConsoleLogger{})
+	journalPath := flag.String("journal", "", "persist SYSTEM events to this write-ahead journal and replay it on start")
+	queueDir := flag.String("queue-dir", "", "keep partition queues in files under this directory instead of memory")
+	tracePath := flag.String("trace", "", "also write causal walks to this file as Chrome/Perfetto trace JSON")
+	metricsAddr := flag.String("metrics-addr", "", "also serve Prometheus metrics at /metrics on this address")
+	otlpEndpoint := flag.String("otlp", "",
+		"also export spans to this OTLP/HTTP traces endpoint, such as http://localhost:4318/v1/traces")
+	logFormat := flag.String("log-format", "console", "engine log output: console, json (slog records on stderr) or none")
+	flag.Parse()
 
-	// start the background system worker
-	eng.StartRealTimeWorker(30 * time.Second)
+	// every enabled sink sees the engine's hooks
+	var sinks []engine.Diagnostic
+	switch *logFormat {
+	case "console":
+		sinks = append(sinks, &engine.ConsoleLogger{})
+	case "json":
+		sinks = append(sinks, engine.NewSlogLogger(slog.New(slog.NewJSONHandler(os.Stderr, nil))))
+	case "none":
+	default:
+		fmt.Printf("❌ Error: unknown -log-format %q\n", *logFormat)
+		os.Exit(1)
+	}
+	if *tracePath != "" {
+		traceFile, err := os.Create(*tracePath)
+		if err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+		trace := telemetry.NewTraceWriter(traceFile)
+		defer closeTrace(trace, traceFile)
+		sinks = append(sinks, trace)
+	}
+	if *otlpEndpoint != "" {
+		exporter := telemetry.NewSpanExporter(*otlpEndpoint)
+		defer flushSpans(exporter)
+		sinks = append(sinks, exporter)
+	}
+	var metrics *telemetry.Metrics
+	if *metricsAddr != "" {
+		metrics = telemetry.NewMetrics()
+		sinks = append(sinks, metrics)
+	}
+	// lineage is always kept so any partition's causal graph can be exported
+	lineage := engine.NewLineageRecorder()
+	sinks = append(sinks, lineage)
+	diag := engine.MultiDiagnostic(sinks...)
+
+	eng := newEngine(diag)
+	if metrics != nil {
+		serveMetrics(*metricsAddr, metrics, eng)
+	}
+	if *queueDir != "" {
+		eng.SetQueueFactory(engine.FileStoreFactory(*queueDir, engine.NewCodec(engine.DefaultRegistry)))
+	}
+
+	// the journal has to be replayed before the worker can run anything
+	if *journalPath != "" {
+		journal, err := engine.OpenJournal(*journalPath)
+		if err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer journal.Close()
+		if err := eng.AttachJournal(journal); err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// start the background system worker, due events are drained on quit
+	worker := eng.NewRealTimeWorker(engine.WorkerConfig{
+		Interval:   30 * time.Second,
+		StopPolicy: engine.StopDrain,
+	})
+	worker.Start()
+	defer stopWorker(worker)
 
 	fmt.Println("\n🚀 HYBRID LOGICAL TIME ENGINE CLI")
 	fmt.Println("=================================")
 	fmt.Println("System Status: Real-Time Worker Active (30s ticks)")
+	if *journalPath != "" {
+		fmt.Printf("SYSTEM Journal: %s\n", *journalPath)
+	}
+	if *metricsAddr != "" {
+		fmt.Printf("Metrics: http://%s/metrics\n", *metricsAddr)
+	}
 	fmt.Println("\nCommands:")
 	fmt.Println("  create-partition <id> <frozen_time_rfc3339>")
 	fmt.Println("----- Example: create-partition user_123 2025-01-01T10:00:00Z")
+	fmt.Println("  delete-partition <id>")
+	fmt.Println("  reset-partition <id> <time_rfc3339>")
+	fmt.Println("  fork <src_id> <dst_id>")
+	fmt.Println("  freeze <id> | unfreeze <id>")
 	fmt.Println("  schedule <partitionID:str> <value:int> <s|h|d|m>")
 	fmt.Println("  advance <partitionID:str> <value:int> <s|h|d|m>")
+	fmt.Println("  cancel <eventID:int>")
+	fmt.Println("  step <partitionID:str> [count:int]")
+	fmt.Println("  next <partitionID:str>")
+	fmt.Println("  pending <partitionID:str>")
+	fmt.Println("  checkpoint <partitionID:str> <name> | rewind <partitionID:str> <name>")
+	fmt.Println("  checkpoints <partitionID:str>")
+	fmt.Println("  save <partitionID:str> <file> | load <file>")
+	fmt.Println("  lineage <partitionID:str> <file.dot|file.mmd>")
+	fmt.Println("  import <scenario_file>")
 	fmt.Println("  status")
 	fmt.Println("  quit")
 	fmt.Println("---------------------------------")
@@ -64,9 +161,71 @@ func main() {
 				continue
 			}
 
-			eng.RegisterPartition(id, clock.NewTestClock(startTime))
+			if err := eng.RegisterPartition(id, clock.NewTestClock(startTime)); err != nil {
+				fmt.Printf("❌ Error: %v\n", err)
+				continue
+			}
 			fmt.Printf("✅ Registered partition '%s' starting at %s\n", id, startTime.Format(time.RFC1123))
 
+		case "delete-partition":
+			// Example: delete-partition user_123
+			if len(args) < 2 {
+				fmt.Println("❌ Usage: delete-partition <id>")
+				continue
+			}
+			if err := eng.DeletePartition(args[1]); err != nil {
+				fmt.Printf("❌ Error: %v\n", err)
+				continue
+			}
+			fmt.Printf("✅ Deleted partition '%s'\n", args[1])
+
+		case "fork":
+			// Example: fork user_123 user_123_payment_fails
+			if len(args) < 3 {
+				fmt.Println("❌ Usage: fork <src_id> <dst_id>")
+				continue
+			}
+			if err := eng.ForkPartition(args[1], args[2]); err != nil {
+				fmt.Printf("❌ Error: %v\n", err)
+				continue
+			}
+			fmt.Printf("✅ Forked partition '%s' into '%s'\n", args[1], args[2])
+
+		case "reset-partition":
+			// Example: reset-partition user_123 2025-01-01T10:00:00Z
+			if len(args) < 3 {
+				fmt.Println("❌ Usage: reset-partition <id> <2025-01-01T10:00:00Z>")
+				continue
+			}
+			resetTime, err := time.Parse(time.RFC3339, args[2])
+			if err != nil {
+				fmt.Printf("❌ Invalid time format: %v\n", err)
+				continue
+			}
+			if err := eng.ResetPartition(args[1], resetTime); err != nil {
+				fmt.Printf("❌ Error: %v\n", err)
+				continue
+			}
+			fmt.Printf("✅ Reset partition '%s' to %s\n", args[1], resetTime.Format(time.RFC1123))
+
+		case "freeze", "unfreeze":
+			// Example: freeze user_123
+			if len(args) < 2 {
+				fmt.Printf("❌ Usage: %s <id>\n", args[0])
+				continue
+			}
+			var err error
+			if args[0] == "freeze" {
+				err = eng.Freeze(args[1])
+			} else {
+				err = eng.Unfreeze(args[1])
+			}
+			if err != nil {
+				fmt.Printf("❌ Error: %v\n", err)
+				continue
+			}
+			fmt.Printf("✅ Partition '%s' %sd\n", args[1], args[0])
+
 		case "schedule":
 			// Example: schedule SYSTEM 30 s
 			// Example: schedule user_1 1 h 0 (0-day trial) -> 0 day trial defaults to 1 minute
@@ -103,9 +262,32 @@ func main() {
 			}
 
 			event := billing.NewSubscriptionCreated(startTime, "CUST-"+id, trialDuration, id)
-			eng.Schedule(event)
+			eventID, err := eng.Schedule(event)
+			if err != nil {
+				fmt.Printf("❌ Error: %v\n", err)
+				continue
+			}
 
-			fmt.Printf("✅ Scheduled '%s' for %s (Trial Duration: %v)\n", id, startTime.Format(time.RFC1123), trialDuration)
+			fmt.Printf("✅ Scheduled '%s' for %s (Trial Duration: %v, Event ID: %d)\n",
+				id, startTime.Format(time.RFC1123), trialDuration, eventID)
+
+		case "cancel":
+			// Example: cancel 3
+			if len(args) < 2 {
+				fmt.Println("❌ Usage: cancel <event_id>")
+				continue
+			}
+			eventID, err := strconv.ParseUint(args[1], 10, 64)
+			if err != nil {
+				fmt.Printf("❌ Invalid event id: %v\n", err)
+				continue
+			}
+
+			if err := eng.Cancel(engine.EventID(eventID)); err != nil {
+				fmt.Printf("❌ Error: %v\n", err)
+				continue
+			}
+			fmt.Printf("✅ Cancelled event %d\n", eventID)
 
 		case "advance":
 			// Example: advance user_123 30 d
@@ -132,17 +314,179 @@ func main() {
 			// 2. Calculate target time relative to the partition
 			target := currentTime.Add(jump)
 
-			// 3. Execute the deterministic advance loop
-			err = eng.Advance(id, target, nil)
-			if err != nil {
+			// 3. Execute the deterministic advance loop, Ctrl+C aborts a runaway walk
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			err = eng.Advance(ctx, id, target)
+			stop()
+
+			var aborted *engine.AdvanceAbortedError
+			if errors.As(err, &aborted) {
+				fmt.Printf("⚠️  Advance interrupted at %s after %d events; run advance again to resume\n",
+					aborted.Reached.Format(time.RFC1123), aborted.Executed)
+			} else if err != nil {
 				fmt.Printf("❌ Advance failed: %v\n", err)
 			}
 
+		case "step":
+			// Example: step user_123 3
+			if len(args) < 2 {
+				fmt.Println("❌ Usage: step <partitionID> [count]")
+				continue
+			}
+			count := 1
+			if len(args) > 2 {
+				count, _ = strconv.Atoi(args[2])
+			}
+
+			executed, err := eng.Step(context.Background(), args[1], count)
+			if err != nil {
+				fmt.Printf("❌ Step failed: %v\n", err)
+				continue
+			}
+			fmt.Printf("✅ Executed %d event(s)\n", executed)
+
+		case "next":
+			// Example: next user_123
+			if len(args) < 2 {
+				fmt.Println("❌ Usage: next <partitionID>")
+				continue
+			}
+
+			now, err := eng.AdvanceToNext(context.Background(), args[1])
+			if err != nil {
+				fmt.Printf("❌ Next failed: %v\n", err)
+				continue
+			}
+			fmt.Printf("✅ Partition '%s' now at %s\n", args[1], now.Format(time.RFC1123))
+
+		case "pending":
+			// Example: pending user_123
+			if len(args) < 2 {
+				fmt.Println("❌ Usage: pending <partitionID>")
+				continue
+			}
+
+			events, err := eng.ListPendingEvents(args[1])
+			if err != nil {
+				fmt.Printf("❌ Error: %v\n", err)
+				continue
+			}
+			fmt.Printf("\n--- Pending Events for '%s' ---\n", args[1])
+			for _, event := range events {
+				fmt.Printf("#%-5d %s  %s\n", event.ID, event.Time.Format("2006-01-02 15:04:05"), event.Name)
+			}
+
+		case "checkpoint":
+			// Example: checkpoint user_123 before_renewal
+			if len(args) < 3 {
+				fmt.Println("❌ Usage: checkpoint <partitionID> <name>")
+				continue
+			}
+			if err := eng.Checkpoint(args[1], args[2]); err != nil {
+				fmt.Printf("❌ Error: %v\n", err)
+				continue
+			}
+			fmt.Printf("✅ Saved checkpoint '%s' for partition '%s'\n", args[2], args[1])
+
+		case "rewind":
+			// Example: rewind user_123 before_renewal
+			if len(args) < 3 {
+				fmt.Println("❌ Usage: rewind <partitionID> <name>")
+				continue
+			}
+			if err := eng.Rewind(args[1], args[2]); err != nil {
+				fmt.Printf("❌ Error: %v\n", err)
+				continue
+			}
+			newTime, _ := eng.GetPartitionTime(args[1])
+			fmt.Printf("✅ Rewound partition '%s' to '%s' (%s)\n", args[1], args[2], newTime.Format(time.RFC1123))
+
+		case "checkpoints":
+			// Example: checkpoints user_123
+			if len(args) < 2 {
+				fmt.Println("❌ Usage: checkpoints <partitionID>")
+				continue
+			}
+
+			checkpoints, err := eng.Checkpoints(args[1])
+			if err != nil {
+				fmt.Printf("❌ Error: %v\n", err)
+				continue
+			}
+			fmt.Printf("\n--- Checkpoints for '%s' ---\n", args[1])
+			for _, checkpoint := range checkpoints {
+				fmt.Printf("%-20s %s  %d pending\n", checkpoint.Name, checkpoint.Time.Format("2006-01-02 15:04:05"), checkpoint.Pending)
+			}
+
+		case "save":
+			// Example: save user_123 fixtures/user_123.json
+			if len(args) < 3 {
+				fmt.Println("❌ Usage: save <partitionID> <file>")
+				continue
+			}
+			if err := saveSnapshot(eng, args[1], args[2]); err != nil {
+				fmt.Printf("❌ Save failed: %v\n", err)
+				continue
+			}
+			fmt.Printf("✅ Saved partition '%s' to %s\n", args[1], args[2])
+
+		case "lineage":
+			// Example: lineage user_123 user_123.mmd
+			if len(args) < 3 {
+				fmt.Println("❌ Usage: lineage <partitionID> <file.dot|file.mmd>")
+				continue
+			}
+			if err := writeLineage(lineage, args[1], args[2]); err != nil {
+				fmt.Printf("❌ Lineage export failed: %v\n", err)
+				continue
+			}
+			fmt.Printf("✅ Wrote the causal lineage of '%s' to %s\n", args[1], args[2])
+
+		case "load":
+			// Example: load fixtures/user_123.json
+			if len(args) < 2 {
+				fmt.Println("❌ Usage: load <file>")
+				continue
+			}
+			id, err := loadSnapshot(eng, args[1])
+			if err != nil {
+				fmt.Printf("❌ Load failed: %v\n", err)
+				continue
+			}
+			fmt.Printf("✅ Restored partition '%s' from %s\n", id, args[1])
+
+		case "import":
+			// Example: import scenarios/dunning.json
+			if len(args) < 2 {
+				fmt.Println("❌ Usage: import <scenario_file>")
+				continue
+			}
+			scheduled, total, err := importScenario(eng, args[1])
+			if total == 0 && err != nil {
+				fmt.Printf("❌ Import failed: %v\n", err)
+				continue
+			}
+			if err != nil {
+				fmt.Printf("❌ Error: %v\n", err)
+			}
+			fmt.Printf("✅ Scheduled %d of %d event(s) from %s\n", scheduled, total, args[1])
+
 		case "status":
-			stats := eng.GetStatus()
 			fmt.Println("\n--- Engine Partition Status ---")
-			for id, info := range stats {
-				fmt.Printf("[%s] %s\n", id, info)
+			for _, status := range eng.Statuses() {
+				now := "-"
+				if !status.Now.IsZero() {
+					now = status.Now.Format("2006-01-02 15:04:05")
+				}
+				next := "-"
+				if status.Pending > 0 {
+					next = fmt.Sprintf("%s @ %s", status.NextEventName, status.NextEventTime.Format("2006-01-02 15:04:05"))
+				}
+				frozen := ""
+				if status.Frozen {
+					frozen = " | FROZEN"
+				}
+				fmt.Printf("[%s] Time: %s | Pending Events: %d | Next: %s%s\n", status.ID, now, status.Pending, next, frozen)
 			}
 
 		case "quit", "exit":
@@ -155,6 +499,98 @@ func main() {
 	}
 }
 
+// newEngine creates the CLI's engine. Declined payments are retried by the
+// engine on SYSTEM and on every partition, including those created by import
+// or restored from an old snapshot.
+func newEngine(diag engine.Diagnostic) *engine.Engine {
+	eng := engine.NewEngine(diag)
+	eng.SetDefaultRetryPolicy(billing.PaymentRetryPolicy)
+	eng.SetRetryPolicy("SYSTEM", billing.PaymentRetryPolicy)
+	return eng
+}
+
+// loadSnapshot restores the partition saved at path and returns its ID.
+func loadSnapshot(eng *engine.Engine, path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	return eng.Restore(file)
+}
+
+// importScenario schedules every event of a scenario file, creating the
+// partitions they belong to as needed. It returns how many of the file's
+// events were scheduled; the error covers those that were not.
+func importScenario(eng *engine.Engine, path string) (int, int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	events, err := engine.NewCodec(engine.DefaultRegistry).UnmarshalEvents(data)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	scheduled := 0
+	var errs []error
+	for _, event := range events {
+		if _, err := eng.Schedule(event); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		scheduled++
+	}
+	return scheduled, len(events), errors.Join(errs...)
+}
+
+// saveSnapshot writes a partition snapshot to path, replacing any existing file.
+func saveSnapshot(eng *engine.Engine, partitionID string, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := eng.Snapshot(partitionID, file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// writeLineage exports a partition's causal graph, as Mermaid for .mmd and
+// .mermaid files and as Graphviz DOT otherwise.
+func writeLineage(lineage *engine.LineageRecorder, partitionID string, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	switch filepath.Ext(path) {
+	case ".mmd", ".mermaid":
+		err = lineage.WriteMermaid(file, partitionID)
+	default:
+		err = lineage.WriteDOT(file, partitionID)
+	}
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// stopWorker drains the real-time worker, giving up after a few seconds so
+// a slow SYSTEM event cannot hang the shutdown.
+func stopWorker(worker *engine.RealTimeWorker) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := worker.Stop(ctx); err != nil {
+		fmt.Printf("⚠️  Real-time worker did not stop cleanly: %v\n", err)
+	}
+}
+
 // parseDuration converts numeric values and unit strings into time.Duration
 func parseDuration(val int, unit string) time.Duration {
 	switch strings.ToLower(unit) {
@@ -171,3 +607,31 @@ func parseDuration(val int, unit string) time.Duration {
 		return time.Duration(val) * time.Second
 	}
 }
+
+// serveMetrics exposes the metrics endpoint for the lifetime of the CLI.
+func serveMetrics(addr string, metrics *telemetry.Metrics, eng *engine.Engine) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler(eng))
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			fmt.Printf("❌ Error serving metrics: %v\n", err)
+		}
+	}()
+}
+
+// flushSpans sends the spans still buffered once the worker has stopped.
+func flushSpans(exporter *telemetry.SpanExporter) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := exporter.Flush(ctx); err != nil {
+		fmt.Printf("❌ Error exporting spans: %v\n", err)
+	}
+}
+
+// closeTrace finishes the trace document once the worker has stopped writing to it.
+func closeTrace(trace *telemetry.TraceWriter, file *os.File) {
+	if err := trace.Close(); err != nil {
+		fmt.Printf("❌ Error writing trace: %v\n", err)
+	}
+	file.Close()
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aaryansingh-dev/hybrid-logical-time-go/internal/billing"
+	"github.com/aaryansingh-dev/hybrid-logical-time-go/internal/clock"
+	"github.com/aaryansingh-dev/hybrid-logical-time-go/internal/engine"
+)
+
+// savedRetryPolicy reads a partition's retry policy back from a snapshot.
+func savedRetryPolicy(t *testing.T, eng *engine.Engine, partitionID string) engine.RetryPolicy {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := eng.Snapshot(partitionID, &buf); err != nil {
+		t.Fatal(err)
+	}
+	var file struct {
+		RetryPolicy engine.RetryPolicy `json:"retry_policy"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &file); err != nil {
+		t.Fatal(err)
+	}
+	return file.RetryPolicy
+}
+
+func TestCLI_RetriesPaymentsOnImportedAndLoadedPartitions(t *testing.T) {
+	dir := t.TempDir()
+	at := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	eng := newEngine(nil)
+
+	scenario, err := engine.NewCodec(engine.DefaultRegistry).MarshalEvents([]engine.Event{
+		billing.NewPaymentAttempt(at, "CUST-1", "imported"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	scenarioPath := filepath.Join(dir, "scenario.json")
+	os.WriteFile(scenarioPath, scenario, 0o644)
+	if scheduled, total, err := importScenario(eng, scenarioPath); err != nil || scheduled != 1 || total != 1 {
+		t.Fatalf("Expected the scenario to be imported, got %d of %d: %v", scheduled, total, err)
+	}
+	// an imported partition has no clock until it is registered
+	if err := eng.RegisterPartition("imported", clock.NewTestClock(at)); err != nil {
+		t.Fatal(err)
+	}
+
+	// a version 1 snapshot has no retry policy of its own
+	snapshotPath := filepath.Join(dir, "old.json")
+	os.WriteFile(snapshotPath, []byte(`{"version": 1, "partition": "loaded", "time": "2025-03-01T00:00:00Z",
+		"past_policy": "clamp", "events": [{"type": "billing.PaymentAttempt", "time": "2025-03-01T01:00:00Z",
+		"data": {"scheduled_at": "2025-03-01T01:00:00Z", "customer_id": "CUST-1", "partition_id": "loaded"}}]}`), 0o644)
+	if id, err := loadSnapshot(eng, snapshotPath); err != nil || id != "loaded" {
+		t.Fatalf("Expected the snapshot to load, got %q: %v", id, err)
+	}
+
+	for _, id := range []string{"imported", "loaded"} {
+		if policy := savedRetryPolicy(t, eng, id); policy != billing.PaymentRetryPolicy {
+			t.Errorf("Expected partition %s to retry payments, got %+v", id, policy)
+		}
+	}
+}
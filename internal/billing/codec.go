@@ -0,0 +1,120 @@
+package billing
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/aaryansingh-dev/hybrid-logical-time-go/internal/engine"
+)
+
+// The billing events keep their fields unexported so they can only be built
+// through their constructors. The JSON forms below are what gets persisted in
+// partition snapshots; the field names are part of the snapshot format and
+// must stay stable.
+
+func init() {
+	engine.RegisterEventType("billing.SubscriptionCreated", func() engine.Event { return &SubscriptionCreated{} })
+	engine.RegisterEventType("billing.TrialEnded", func() engine.Event { return &TrialEnded{} })
+	engine.RegisterEventType("billing.InvoiceCreated", func() engine.Event { return &InvoiceCreated{} })
+	engine.RegisterEventType("billing.PaymentAttempt", func() engine.Event { return &PaymentAttempt{} })
+}
+
+type subscriptionCreatedJSON struct {
+	ScheduledAt   time.Time     `json:"scheduled_at"`
+	CustomerID    string        `json:"customer_id"`
+	TrialDuration time.Duration `json:"trial_duration"`
+	PartitionID   string        `json:"partition_id"`
+}
+
+func (billingEvent *SubscriptionCreated) MarshalJSON() ([]byte, error) {
+	return json.Marshal(subscriptionCreatedJSON{
+		ScheduledAt:   billingEvent.scheduledAt,
+		CustomerID:    billingEvent.customerID,
+		TrialDuration: billingEvent.trialDuration,
+		PartitionID:   billingEvent.partitionID,
+	})
+}
+
+func (billingEvent *SubscriptionCreated) UnmarshalJSON(data []byte) error {
+	var decoded subscriptionCreatedJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*billingEvent = *NewSubscriptionCreated(decoded.ScheduledAt, decoded.CustomerID, decoded.TrialDuration, decoded.PartitionID)
+	return nil
+}
+
+// trialEndedJSON and invoiceCreatedJSON share a shape, but are kept separate
+// so either event can grow fields without touching the other.
+type trialEndedJSON struct {
+	ScheduledAt time.Time `json:"scheduled_at"`
+	CustomerID  string    `json:"customer_id"`
+	PartitionID string    `json:"partition_id"`
+}
+
+func (billingEvent *TrialEnded) MarshalJSON() ([]byte, error) {
+	return json.Marshal(trialEndedJSON{
+		ScheduledAt: billingEvent.scheduledAt,
+		CustomerID:  billingEvent.customerID,
+		PartitionID: billingEvent.partitionID,
+	})
+}
+
+func (billingEvent *TrialEnded) UnmarshalJSON(data []byte) error {
+	var decoded trialEndedJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*billingEvent = *NewTrialEnded(decoded.ScheduledAt, decoded.CustomerID, decoded.PartitionID)
+	return nil
+}
+
+type invoiceCreatedJSON struct {
+	ScheduledAt time.Time `json:"scheduled_at"`
+	CustomerID  string    `json:"customer_id"`
+	PartitionID string    `json:"partition_id"`
+}
+
+func (billingEvent *InvoiceCreated) MarshalJSON() ([]byte, error) {
+	return json.Marshal(invoiceCreatedJSON{
+		ScheduledAt: billingEvent.scheduledAt,
+		CustomerID:  billingEvent.customerID,
+		PartitionID: billingEvent.partitionID,
+	})
+}
+
+func (billingEvent *InvoiceCreated) UnmarshalJSON(data []byte) error {
+	var decoded invoiceCreatedJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*billingEvent = *NewInvoiceCreated(decoded.ScheduledAt, decoded.CustomerID, decoded.PartitionID)
+	return nil
+}
+
+// paymentAttemptJSON only reads current_retry, from snapshots written before
+// the engine counted retries; it is handed to the engine through RetryCount.
+type paymentAttemptJSON struct {
+	ScheduledAt  time.Time `json:"scheduled_at"`
+	CustomerID   string    `json:"customer_id"`
+	PartitionID  string    `json:"partition_id"`
+	CurrentRetry int       `json:"current_retry,omitempty"`
+}
+
+func (billingEvent *PaymentAttempt) MarshalJSON() ([]byte, error) {
+	return json.Marshal(paymentAttemptJSON{
+		ScheduledAt: billingEvent.scheduledAt,
+		CustomerID:  billingEvent.customerID,
+		PartitionID: billingEvent.partitionID,
+	})
+}
+
+func (billingEvent *PaymentAttempt) UnmarshalJSON(data []byte) error {
+	var decoded paymentAttemptJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*billingEvent = *NewPaymentAttempt(decoded.ScheduledAt, decoded.CustomerID, decoded.PartitionID)
+	billingEvent.legacyRetry = decoded.CurrentRetry
+	return nil
+}
@@ -0,0 +1,108 @@
+package billing
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/aaryansingh-dev/hybrid-logical-time-go/internal/clock"
+	"github.com/aaryansingh-dev/hybrid-logical-time-go/internal/engine"
+)
+
+func TestSnapshotRestore_BillingEvents(t *testing.T) {
+	id := "customer_60_days_dunning"
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	src := engine.NewEngine(nil)
+	src.RegisterPartition(id, clock.NewTestClock(start))
+	src.Schedule(NewSubscriptionCreated(start.Add(time.Hour), "CUST-1", 14*24*time.Hour, id))
+	src.Schedule(NewTrialEnded(start.Add(2*time.Hour), "CUST-1", id))
+	src.Schedule(NewInvoiceCreated(start.Add(3*time.Hour), "CUST-1", id))
+	src.Schedule(NewPaymentAttempt(start.Add(4*time.Hour), "CUST-1", id))
+
+	var buf bytes.Buffer
+	if err := src.Snapshot(id, &buf); err != nil {
+		t.Fatalf("Snapshot failed: %v", err)
+	}
+
+	dst := engine.NewEngine(nil)
+	if _, err := dst.Restore(&buf); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+
+	// snapshotting the restored partition again must reproduce the file byte for byte
+	var again bytes.Buffer
+	if err := dst.Snapshot(id, &again); err != nil {
+		t.Fatal(err)
+	}
+	var first bytes.Buffer
+	src.Snapshot(id, &first)
+	if first.String() != again.String() {
+		t.Errorf("Snapshot of restored partition differs:\n%s\nvs\n%s", first.String(), again.String())
+	}
+}
+
+func TestPaymentAttempt_JSONRoundTrip(t *testing.T) {
+	at := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	original := NewPaymentAttempt(at, "CUST-1", "p")
+
+	data, err := original.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded PaymentAttempt
+	if err := decoded.UnmarshalJSON(data); err != nil {
+		t.Fatal(err)
+	}
+	if decoded != *original {
+		t.Errorf("Round trip changed the event: got %+v, want %+v", decoded, *original)
+	}
+}
+
+func TestBillingEvents_RegisteredByDefault(t *testing.T) {
+	codec := engine.NewCodec(engine.DefaultRegistry)
+	at := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	events := []engine.Event{
+		NewSubscriptionCreated(at, "CUST-1", 14*24*time.Hour, "p"),
+		NewTrialEnded(at, "CUST-1", "p"),
+		NewInvoiceCreated(at, "CUST-1", "p"),
+		NewPaymentAttempt(at, "CUST-1", "p"),
+	}
+
+	data, err := codec.MarshalEvents(events)
+	if err != nil {
+		t.Fatalf("Expected billing events to be encodable out of the box: %v", err)
+	}
+	decoded, err := codec.UnmarshalEvents(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range events {
+		if decoded[i].Name() != events[i].Name() || !decoded[i].Time().Equal(events[i].Time()) {
+			t.Errorf("Event %d: got %s, want %s", i, decoded[i].Name(), events[i].Name())
+		}
+	}
+}
+
+func TestPaymentAttempt_Version1RetryCount(t *testing.T) {
+	data := []byte(`{"scheduled_at": "2025-03-01T00:00:00Z", "customer_id": "CUST-1", "partition_id": "p", "current_retry": 2}`)
+
+	var decoded PaymentAttempt
+	if err := decoded.UnmarshalJSON(data); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.RetryCount() != 2 {
+		t.Errorf("Expected the version 1 retry count to be handed to the engine, got %d", decoded.RetryCount())
+	}
+
+	// the engine keeps the count from now on, so it is not written back
+	again, err := decoded.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(again, []byte("current_retry")) {
+		t.Errorf("Expected current_retry to be dropped, got %s", again)
+	}
+}
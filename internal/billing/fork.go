@@ -0,0 +1,23 @@
+package billing
+
+import "github.com/aaryansingh-dev/hybrid-logical-time-go/internal/engine"
+
+// The billing events carry their partition so the events they produce land in
+// the same one. ForkTo rewrites it, which is what lets a forked partition run
+// its own copy of the billing lifecycle.
+
+func (billingEvent *SubscriptionCreated) ForkTo(partitionID string) engine.Event {
+	return NewSubscriptionCreated(billingEvent.scheduledAt, billingEvent.customerID, billingEvent.trialDuration, partitionID)
+}
+
+func (billingEvent *TrialEnded) ForkTo(partitionID string) engine.Event {
+	return NewTrialEnded(billingEvent.scheduledAt, billingEvent.customerID, partitionID)
+}
+
+func (billingEvent *InvoiceCreated) ForkTo(partitionID string) engine.Event {
+	return NewInvoiceCreated(billingEvent.scheduledAt, billingEvent.customerID, partitionID)
+}
+
+func (billingEvent *PaymentAttempt) ForkTo(partitionID string) engine.Event {
+	return NewPaymentAttempt(billingEvent.scheduledAt, billingEvent.customerID, partitionID)
+}
@@ -30,6 +30,6 @@ func (billingEvent *InvoiceCreated) Execute(timeProvider clock.TimeProvider) []e
 	paymentTime := timeProvider.Now().Add(10 * time.Minute)
 
 	return []engine.Event{
-		NewPaymentAttempt(paymentTime, billingEvent.customerID, billingEvent.partitionID, 0),
+		NewPaymentAttempt(paymentTime, billingEvent.customerID, billingEvent.partitionID),
 	}
 }
@@ -0,0 +1,70 @@
+package billing
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aaryansingh-dev/hybrid-logical-time-go/internal/clock"
+	"github.com/aaryansingh-dev/hybrid-logical-time-go/internal/engine"
+)
+
+func TestSubscription_TrialLeadsToInvoiceAndPayment(t *testing.T) {
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	trial := 14 * 24 * time.Hour
+	recorder := engine.NewTimelineRecorder()
+	eng := engine.NewEngine(recorder)
+	eng.RegisterPartition("cust", clock.NewTestClock(start))
+	eng.Schedule(NewSubscriptionCreated(start, "CUST-1", trial, "cust"))
+
+	if err := eng.Advance(context.Background(), "cust", start.Add(trial+24*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	timeline := recorder.Timeline("cust")
+
+	if gap, ok := timeline.GapBetween("SubscriptionCreated", "TrialEnded"); !ok || gap != trial {
+		t.Errorf("Expected the trial to end %s after the subscription, got %s\n%s", trial, gap, timeline)
+	}
+	if gap, ok := timeline.GapBetween("TrialEnded", "InvoiceCreated"); !ok || gap != time.Hour {
+		t.Errorf("Expected the invoice an hour after the trial, got %s\n%s", gap, timeline)
+	}
+	// whether the payment succeeds is random, but the first attempt is not
+	if first, ok := timeline.Nth("PaymentAttempt", 1); !ok || !first.Time.Equal(start.Add(trial+time.Hour+10*time.Minute)) {
+		t.Errorf("Expected the first payment attempt 10 minutes after the invoice, got %+v\n%s", first, timeline)
+	}
+	if timeline.Named("InvoiceCreated").Between(start, start.Add(trial)).Len() != 0 {
+		t.Errorf("Expected no invoice during the trial\n%s", timeline)
+	}
+}
+
+func TestPaymentAttempt_RetriesUnderThePaymentPolicy(t *testing.T) {
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	recorder := engine.NewTimelineRecorder()
+	eng := engine.NewEngine(recorder)
+
+	// a decline is random, but across 100 customers at least one is all but certain
+	retried := 0
+	for i := 0; i < 100; i++ {
+		id := fmt.Sprintf("cust_%d", i)
+		eng.RegisterPartition(id, clock.NewTestClock(start))
+		eng.SetRetryPolicy(id, PaymentRetryPolicy)
+		eng.Schedule(NewPaymentAttempt(start, "CUST-1", id))
+		if err := eng.Advance(context.Background(), id, start.Add(24*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+
+		gaps := recorder.Timeline(id).GapsBetween("PaymentAttempt", "PaymentAttempt")
+		for n, gap := range gaps {
+			if gap != time.Duration(n+1)*time.Hour {
+				t.Errorf("Expected retry %d of %s %dh after the previous attempt, got %s", n+1, id, n+1, gap)
+			}
+		}
+		if len(gaps) > 0 {
+			retried++
+		}
+	}
+	if retried == 0 {
+		t.Error("Expected at least one declined payment to be retried")
+	}
+}
@@ -1,6 +1,7 @@
 package billing
 
 import (
+	"errors"
 	"fmt"
 	"math/rand/v2"
 	"time"
@@ -9,19 +10,32 @@ import (
 	"github.com/aaryansingh-dev/hybrid-logical-time-go/internal/engine"
 )
 
+// ErrPaymentDeclined is the error a failed PaymentAttempt reports to the
+// engine.
+var ErrPaymentDeclined = errors.New("payment declined")
+
+// PaymentRetryPolicy is the dunning schedule for PaymentAttempt: up to three
+// retries, 1h, 2h and then 3h after each decline. Partitions running the
+// billing lifecycle need it set with SetRetryPolicy, or for every new
+// partition with SetDefaultRetryPolicy; without it, a declined payment goes
+// straight to the dead-letter queue.
+var PaymentRetryPolicy = engine.LinearRetry(3, time.Hour)
+
+// PaymentAttempt charges the customer for an invoice. It is Fallible: a
+// decline is returned to the engine, which retries it under the partition's
+// RetryPolicy.
 type PaymentAttempt struct {
-	scheduledAt  time.Time
-	customerID   string
-	partitionID  string
-	currentRetry int
+	scheduledAt time.Time
+	customerID  string
+	partitionID string
+	legacyRetry int // current_retry of a version 1 snapshot
 }
 
-func NewPaymentAttempt(at time.Time, customerID string, partitionID string, retryCount int) *PaymentAttempt {
+func NewPaymentAttempt(at time.Time, customerID string, partitionID string) *PaymentAttempt {
 	return &PaymentAttempt{
-		scheduledAt:  at,
-		customerID:   customerID,
-		partitionID:  partitionID,
-		currentRetry: retryCount,
+		scheduledAt: at,
+		customerID:  customerID,
+		partitionID: partitionID,
 	}
 }
 
@@ -29,24 +43,22 @@ func (billingEvent *PaymentAttempt) Time() time.Time { return billingEvent.sched
 func (billingEvent *PaymentAttempt) Name() string    { return getEventName(billingEvent) }
 func (billingEvent *PaymentAttempt) ClockID() string { return billingEvent.partitionID }
 
+// RetryCount reports the retries an attempt decoded from a version 1
+// snapshot had already used up, so that Restore can carry them over.
+func (billingEvent *PaymentAttempt) RetryCount() int { return billingEvent.legacyRetry }
+
+// Execute is only used outside the engine; a decline produces no events.
 func (billingEvent *PaymentAttempt) Execute(timeProvider clock.TimeProvider) []engine.Event {
-	const MaxRetries = 3
+	events, _ := billingEvent.TryExecute(timeProvider)
+	return events
+}
 
+func (billingEvent *PaymentAttempt) TryExecute(timeProvider clock.TimeProvider) ([]engine.Event, error) {
 	// Simulation: 20% failure rate to demonstrate error handling causality
 	if rand.Float64() < 0.2 {
-		if billingEvent.currentRetry >= MaxRetries {
-			fmt.Printf("[BILLING] CRITICAL: Payment permanently failed for customer %s after %d retries\n",
-				billingEvent.customerID, billingEvent.currentRetry)
-			return nil
-		}
-
-		// Implement Linear Backoff: Each retry waits longer (1hr, 2hr, 3hr...)
-		backoffDuration := time.Duration(billingEvent.currentRetry+1) * time.Hour
-		retryTime := timeProvider.Now().Add(backoffDuration)
-
-		return []engine.Event{
-			NewPaymentAttempt(retryTime, billingEvent.customerID, billingEvent.partitionID, billingEvent.currentRetry+1),
-		}
+		fmt.Printf("[BILLING] DECLINED: Payment failed for customer %s at %s\n",
+			billingEvent.customerID, timeProvider.Now().Format(time.RFC3339))
+		return nil, ErrPaymentDeclined
 	}
 
 	fmt.Printf("[BILLING] SUCCESS: Payment processed for %s at %s\n",
@@ -56,5 +68,5 @@ func (billingEvent *PaymentAttempt) Execute(timeProvider clock.TimeProvider) []e
 	
 	return []engine.Event{
 		NewInvoiceCreated(nextInvoiceCycle, billingEvent.customerID, billingEvent.partitionID),
-	}
+	}, nil
 }
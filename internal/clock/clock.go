@@ -3,13 +3,19 @@
 // deterministic virtual time for simulations.
 package clock
 
-import "time"
+import (
+	"sync"
+	"time"
+)
 
 // TestClock is a stateful virtual clock that only advances when explicitly
 // commanded. It is used in deterministic simulations to "teleport" between
 // scheduled events without waiting for real-world time to pass.
+// It is safe for concurrent use: readers may call Now while the engine is
+// walking the partition forward.
 type TestClock struct {
 	now time.Time
+	mu  sync.RWMutex
 }
 
 // NewTestClock creates and returns a new TestClock initialized to the
@@ -21,11 +27,17 @@ func NewTestClock(start time.Time) *TestClock {
 // Now returns the current logical time held by the TestClock.
 // This satisfies the TimeProvider interface.
 func (c *TestClock) Now() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
 	return c.now
 }
 
 // Set updates the internal logical time of the clock to the provided timestamp.
 // This is typically called by the engine during a "temporal jump" or "causal walk."
 func (c *TestClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	c.now = t
 }
@@ -0,0 +1,339 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aaryansingh-dev/hybrid-logical-time-go/internal/clock"
+)
+
+// AdvanceLimits bounds a single causal walk. A zero value for any field
+// means that budget is unlimited.
+type AdvanceLimits struct {
+	// MaxEvents is the maximum number of events the walk may execute.
+	MaxEvents int
+
+	// MaxDuration is the maximum wall-clock time the walk may run for.
+	MaxDuration time.Duration
+}
+
+var (
+	// ErrEventBudgetExceeded is the cause of an abort triggered by AdvanceLimits.MaxEvents.
+	ErrEventBudgetExceeded = errors.New("event budget exceeded")
+
+	// ErrTimeBudgetExceeded is the cause of an abort triggered by AdvanceLimits.MaxDuration.
+	ErrTimeBudgetExceeded = errors.New("wall-clock budget exceeded")
+)
+
+// check returns the reason the walk must stop before executing another event,
+// or nil if it may continue.
+func (limits AdvanceLimits) check(ctx context.Context, started time.Time, executed int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if limits.MaxEvents > 0 && executed >= limits.MaxEvents {
+		return ErrEventBudgetExceeded
+	}
+	if limits.MaxDuration > 0 && time.Since(started) >= limits.MaxDuration {
+		return ErrTimeBudgetExceeded
+	}
+	return nil
+}
+
+// AdvanceAbortedError reports a walk that stopped before reaching its target.
+// The partition is left consistent at Reached: every event up to that point
+// has executed and its causal children are queued, so calling Advance again
+// with the same target resumes where this walk stopped.
+type AdvanceAbortedError struct {
+	PartitionID string
+	Target      time.Time // the time the walk was asked to reach
+	Reached     time.Time // the partition's clock when the walk stopped
+	Executed    int       // events executed before stopping
+	Cause       error     // context.Canceled, context.DeadlineExceeded or one of the budget errors
+}
+
+func (e *AdvanceAbortedError) Error() string {
+	return fmt.Sprintf("advance of partition %s aborted at %s (target %s, %d events executed): %v",
+		e.PartitionID,
+		e.Reached.Format(time.RFC3339),
+		e.Target.Format(time.RFC3339),
+		e.Executed,
+		e.Cause)
+}
+
+// Unwrap exposes the cause, so errors.Is(err, context.Canceled) works.
+func (e *AdvanceAbortedError) Unwrap() error {
+	return e.Cause
+}
+
+// ErrPartitionIdle is returned by AdvanceToNext when there is no pending
+// event to jump to.
+var ErrPartitionIdle = errors.New("partition has no pending events")
+
+// Step executes exactly the next n events of a partition, one causal step at
+// a time, and returns how many actually ran. It stops early without error if
+// the partition runs out of events. The clock is left at the last executed
+// event rather than being moved to any target.
+func (engine *Engine) Step(ctx context.Context, partitionID string, n int) (int, error) {
+	if n <= 0 {
+		return 0, fmt.Errorf("invalid step count %d", n)
+	}
+	result, err := engine.walk(ctx, partitionID, walkSpec{maxSteps: n})
+	return result.executed, err
+}
+
+// AdvanceToNext jumps a partition to the time of its next pending event and
+// executes everything due at that instant, including zero-delay causal events
+// produced along the way. It returns the partition's new time.
+func (engine *Engine) AdvanceToNext(ctx context.Context, partitionID string) (time.Time, error) {
+	queue, provider, err := engine.getPartition(partitionID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	next := queue.Peek()
+	if next == nil {
+		return provider.Now(), fmt.Errorf("advance partition %s to next event: %w", partitionID, ErrPartitionIdle)
+	}
+
+	// an event queued in the past under PastAllow is due immediately
+	to := next.Time()
+	if to.Before(provider.Now()) {
+		to = provider.Now()
+	}
+
+	if _, err := engine.walk(ctx, partitionID, walkSpec{to: to, land: true}); err != nil {
+		return provider.Now(), err
+	}
+	return provider.Now(), nil
+}
+
+// RunUntilIdle executes events until the partition's heap is empty and
+// returns how many ran. A partition that keeps producing events, such as a
+// subscription that invoices every month forever, never goes idle; such walks
+// end when they hit LoopLimits.MaxEventsPerAdvance or ctx is cancelled.
+func (engine *Engine) RunUntilIdle(ctx context.Context, partitionID string) (int, error) {
+	result, err := engine.walk(ctx, partitionID, walkSpec{})
+	return result.executed, err
+}
+
+// RunUntil executes events until one of them satisfies the predicate, and
+// returns that event. The predicate is evaluated after each event executes, so
+// its causal events have already been scheduled. If the partition goes idle
+// first, RunUntil returns a nil event and no error.
+func (engine *Engine) RunUntil(ctx context.Context, partitionID string, predicate func(Event) bool) (Event, error) {
+	if predicate == nil {
+		return nil, fmt.Errorf("invalid predicate: nil")
+	}
+	result, err := engine.walk(ctx, partitionID, walkSpec{until: predicate})
+	return result.matched, err
+}
+
+// walkSpec describes where a causal walk stops. Every stepping API is a walk
+// with a different spec, so they all share the same ordering, loop protection,
+// cancellation and Diagnostic hooks.
+type walkSpec struct {
+	to       time.Time        // only execute events up to this time; zero means unbounded
+	land     bool             // move the clock to `to` once no more events are due
+	maxSteps int              // stop after this many events; zero means unbounded
+	until    func(Event) bool // stop after the first executed event matching this
+	limits   AdvanceLimits
+}
+
+// walkResult reports what a walk did.
+type walkResult struct {
+	executed int
+	matched  Event
+}
+
+// due reports whether the walk should execute the next pending event.
+func (spec walkSpec) due(next Event) bool {
+	return next != nil && (spec.to.IsZero() || !next.Time().After(spec.to))
+}
+
+// walk is the causal walk behind Advance and the stepping APIs.
+// Only non-SYSTEM partitions using a TestClock can be walked.
+func (engine *Engine) walk(ctx context.Context, partitionID string, spec walkSpec) (walkResult, error) {
+	var result walkResult
+
+	if partitionID == "SYSTEM" {
+		return result, fmt.Errorf("invalid operation: the SYSTEM partition follows wall-clock time and cannot be advanced manually")
+	}
+
+	// Resolve the specific queue and clock for this tenant
+	queue, provider, err := engine.getPartition(partitionID)
+	if err != nil {
+		return result, err
+	}
+
+	testClock, ok := provider.(*clock.TestClock)
+	if !ok {
+		return result, fmt.Errorf(
+			"partition %s is not a TestClock; manual time warping is only supported for simulation partitions", partitionID)
+	}
+
+	state := engine.state(partitionID)
+	if err := state.lockWalk(ctx); err != nil {
+		return result, &AdvanceAbortedError{PartitionID: partitionID, Target: spec.to, Reached: testClock.Now(), Cause: err}
+	}
+	defer state.unlockWalk()
+
+	// the partition may have been deleted, re-created or frozen while we
+	// waited for the walk slot
+	if current, _, err := engine.getPartition(partitionID); err != nil {
+		return result, err
+	} else if current != queue {
+		return result, fmt.Errorf("partition %s was re-created while waiting to advance", partitionID)
+	}
+	if state.isFrozen() {
+		return result, fmt.Errorf("advance partition %s: %w", partitionID, ErrPartitionFrozen)
+	}
+
+	if !spec.to.IsZero() && spec.to.Before(testClock.Now()) {
+		return result, fmt.Errorf("invalid target: %s is before partition %s time %s",
+			spec.to.Format(time.RFC3339), partitionID, testClock.Now().Format(time.RFC3339))
+	}
+
+	if engine.diag != nil {
+		// open-ended walks have no target, report the first event they will reach
+		target := spec.to
+		if target.IsZero() {
+			if next := queue.Peek(); next != nil {
+				target = next.Time()
+			} else {
+				target = testClock.Now()
+			}
+		}
+		engine.diag.OnAdvanceStart(partitionID, testClock.Now(), target)
+	}
+	engine.observe(EngineEvent{
+		Kind:        EngineAdvanceStarted,
+		PartitionID: partitionID,
+		VirtualTime: testClock.Now(),
+		Target:      spec.to,
+	})
+
+	started := time.Now()
+	from := testClock.Now()
+
+	// finish records the walk and reports how it ended; it returns err so
+	// that every exit reads `return result, finish(err)`
+	finish := func(err error) error {
+		state.setLastAdvance(AdvanceStats{
+			From:      from,
+			To:        testClock.Now(),
+			Executed:  result.executed,
+			StartedAt: started,
+			Duration:  time.Since(started),
+		})
+		if engine.diag != nil {
+			engine.diag.OnAdvanceFinish(partitionID, testClock.Now())
+		}
+		engine.observe(EngineEvent{
+			Kind:        EngineAdvanceFinished,
+			PartitionID: partitionID,
+			VirtualTime: testClock.Now(),
+			Target:      spec.to,
+			Executed:    result.executed,
+			Err:         err,
+		})
+		return err
+	}
+
+	guard := newLoopGuard(partitionID, engine.getLoopLimits())
+
+	for {
+		next := queue.Peek()
+
+		// a failing store looks empty, it must not be mistaken for the end of the walk
+		if err := queue.Err(); err != nil {
+			return result, finish(fmt.Errorf("advance of partition %s failed at %s: %w",
+				partitionID, testClock.Now().Format(time.RFC3339), err))
+		}
+
+		// EXIT CONDITION: If no more events exist OR the next event is
+		// scheduled for a time after our target, we jump to target and stop.
+		if !spec.due(next) {
+			if spec.land {
+				testClock.Set(spec.to)
+			}
+			return result, finish(nil)
+		}
+
+		// ABORT CONDITION: there is still work before the target, but the
+		// caller gave up or the budget ran out. Stop between events so the
+		// partition is left in a consistent, resumable state.
+		if cause := spec.limits.check(ctx, started, result.executed); cause != nil {
+			return result, finish(&AdvanceAbortedError{
+				PartitionID: partitionID,
+				Target:      spec.to,
+				Reached:     testClock.Now(),
+				Executed:    result.executed,
+				Cause:       cause,
+			})
+		}
+
+		// Cancel and Schedule do not take the walk slot, so the head may have
+		// changed since it was peeked; only pop it if it is still due
+		item, ok := queue.popDue(spec.to)
+		if !ok {
+			continue
+		}
+		id, event := item.ID, item.Event
+
+		// teleport to the next event; one queued in the past under
+		// PastAllow runs at the current time
+		if event.Time().After(testClock.Now()) {
+			testClock.Set(event.Time())
+		}
+
+		// a loop or storm is a bug in the event logic, not something to
+		// resume from, so the walk fails instead of aborting
+		if err := guard.admit(id, event, testClock.Now()); err != nil {
+			// leave the offender pending, in its place, so the partition can
+			// be inspected; if the store refuses it, say that it is lost
+			if restoreErr := queue.restore(item); restoreErr != nil {
+				err = errors.Join(err, fmt.Errorf("event %d was dropped: %w", id, restoreErr))
+			}
+			return result, finish(err)
+		}
+
+		children, err := engine.execute(partitionID, id, event, testClock)
+		result.executed++
+		if err != nil {
+			return result, finish(fmt.Errorf("advance of partition %s failed at %s: %w",
+				partitionID, testClock.Now().Format(time.RFC3339), err))
+		}
+		guard.record(id, children)
+
+		if verifier, ok := engine.diag.(Verifier); ok {
+			if err := verifier.Verify(partitionID); err != nil {
+				return result, finish(fmt.Errorf("advance of partition %s failed at %s: %w",
+					partitionID, testClock.Now().Format(time.RFC3339), err))
+			}
+		}
+
+		// we already hold the walk slot, so the marker is captured directly
+		if marker, ok := unwrapEvent(event).(*CheckpointEvent); ok {
+			if err := state.saveCheckpoint(engine.getCodec(), marker.Label, testClock.Now(), queue); err != nil {
+				return result, finish(fmt.Errorf("advance of partition %s failed at %s: checkpoint %q: %w",
+					partitionID, testClock.Now().Format(time.RFC3339), marker.Label, err))
+			}
+			engine.observeCheckpoint(partitionID, marker.Label, testClock.Now())
+		}
+
+		// STEP CONDITIONS: the caller asked for a fixed number of events or
+		// for the first event matching a predicate.
+		// the predicate sees the event as scheduled, not the wrapper a
+		// reschedule, clamp or retry put around it
+		if spec.until != nil && spec.until(unwrapEvent(event)) {
+			result.matched = unwrapEvent(event)
+			return result, finish(nil)
+		}
+		if spec.maxSteps > 0 && result.executed >= spec.maxSteps {
+			return result, finish(nil)
+		}
+	}
+}
@@ -0,0 +1,282 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/aaryansingh-dev/hybrid-logical-time-go/internal/clock"
+)
+
+// ErrCheckpointNotFound is returned by Rewind when the partition has no
+// checkpoint with the given name.
+var ErrCheckpointNotFound = errors.New("checkpoint not found")
+
+// Stateful is implemented by domain components whose state should rewind
+// together with a partition, such as an in-memory ledger fed by its events.
+// SaveState must return a value that later changes to the component do not
+// affect; RestoreState receives that same value back.
+type Stateful interface {
+	SaveState() any
+	RestoreState(state any)
+}
+
+// checkpoint is everything Rewind needs to put a partition back. Events are
+// kept encoded, so that changes made to them after the checkpoint do not leak
+// into it and every Rewind starts from fresh copies.
+type checkpoint struct {
+	time        time.Time
+	events      []savedEvent // pending, in execution order
+	states      []any        // one per component in partitionState.stateful at the time
+	attempts    map[EventID]int
+	deadLetters []DeadLetter
+	lettered    []EncodedEvent // the event of each dead letter
+}
+
+// savedEvent is a pending event captured by a checkpoint.
+type savedEvent struct {
+	id    EventID
+	event EncodedEvent
+}
+
+// CheckpointInfo describes a checkpoint without exposing its contents.
+type CheckpointInfo struct {
+	Name    string
+	Time    time.Time
+	Pending int
+}
+
+// CheckpointEvent records a checkpoint named Label when a walk reaches it, so
+// a scenario can mark the points it may want to rewind to. It is ordered like
+// any other event: the checkpoint holds the partition as it is after
+// everything that runs before the marker, and without the marker itself.
+type CheckpointEvent struct {
+	At        time.Time `json:"at"`
+	Partition string    `json:"partition"`
+	Label     string    `json:"label"`
+}
+
+// NewCheckpointEvent returns a marker recording checkpoint name in the
+// partition at the given time.
+func NewCheckpointEvent(at time.Time, partitionID string, name string) *CheckpointEvent {
+	return &CheckpointEvent{At: at, Partition: partitionID, Label: name}
+}
+
+func (e *CheckpointEvent) Time() time.Time                       { return e.At }
+func (e *CheckpointEvent) Name() string                          { return "Checkpoint(" + e.Label + ")" }
+func (e *CheckpointEvent) ClockID() string                       { return e.Partition }
+func (e *CheckpointEvent) Execute(tp clock.TimeProvider) []Event { return nil }
+
+func (e *CheckpointEvent) ForkTo(partitionID string) Event {
+	return NewCheckpointEvent(e.At, partitionID, e.Label)
+}
+
+func init() {
+	RegisterEventType("engine.Checkpoint", func() Event { return &CheckpointEvent{} })
+}
+
+// RegisterStateful adds a component whose state is saved with every later
+// checkpoint of the partition and restored by Rewind.
+func (engine *Engine) RegisterStateful(partitionID string, component Stateful) error {
+	if _, _, err := engine.getPartition(partitionID); err != nil {
+		return err
+	}
+
+	state := engine.state(partitionID)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	state.stateful = append(state.stateful, component)
+	return nil
+}
+
+// Checkpoint saves the partition's clock, pending events and the state of its
+// Stateful components under name, replacing any earlier checkpoint with that
+// name. If a walk is in progress, Checkpoint waits for it to finish; use a
+// CheckpointEvent to mark a point in the middle of a walk.
+//
+// Pending and dead-lettered events are deep-copied through the engine's Codec,
+// like ForkPartition does, so changes made to an event after the checkpoint
+// are not rewound into it. Every such event must therefore be of a type
+// registered in the engine's Registry.
+func (engine *Engine) Checkpoint(partitionID string, name string) error {
+	if partitionID == "SYSTEM" {
+		return fmt.Errorf("invalid operation: the SYSTEM partition follows wall-clock time and cannot be checkpointed")
+	}
+
+	state, err := engine.lockPartition(partitionID)
+	if err != nil {
+		return err
+	}
+	defer state.unlockWalk()
+
+	queue, provider, err := engine.getPartition(partitionID)
+	if err != nil {
+		return err
+	}
+	if _, ok := provider.(*clock.TestClock); !ok {
+		return fmt.Errorf("partition %s is not a TestClock; only simulation partitions can be checkpointed", partitionID)
+	}
+
+	if err := state.saveCheckpoint(engine.getCodec(), name, provider.Now(), queue); err != nil {
+		return fmt.Errorf("checkpoint partition %s as %q: %w", partitionID, name, err)
+	}
+	engine.observeCheckpoint(partitionID, name, provider.Now())
+	return nil
+}
+
+// saveCheckpoint captures the partition. The caller must hold the walk slot.
+// If an event cannot be encoded, no checkpoint is saved.
+func (state *partitionState) saveCheckpoint(codec *Codec, name string, now time.Time, queue *EventQueue) error {
+	items := queue.ordered()
+	events := make([]savedEvent, len(items))
+	for i, item := range items {
+		encoded, err := codec.Encode(item.Event)
+		if err != nil {
+			return err
+		}
+		events[i] = savedEvent{id: item.ID, event: encoded}
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	saved := &checkpoint{
+		time:        now,
+		events:      events,
+		attempts:    maps.Clone(state.attempts),
+		deadLetters: slices.Clone(state.deadLetters),
+	}
+	for _, letter := range state.deadLetters {
+		encoded, err := codec.Encode(letter.event)
+		if err != nil {
+			return err
+		}
+		saved.lettered = append(saved.lettered, encoded)
+	}
+	for _, component := range state.stateful {
+		saved.states = append(saved.states, component.SaveState())
+	}
+	if state.checkpoints == nil {
+		state.checkpoints = make(map[string]*checkpoint)
+	}
+	state.checkpoints[name] = saved
+	return nil
+}
+
+// decode returns fresh copies of the checkpoint's pending events and dead
+// letters.
+func (saved *checkpoint) decode(codec *Codec) ([]QueuedEvent, []DeadLetter, error) {
+	items := make([]QueuedEvent, len(saved.events))
+	for i, pending := range saved.events {
+		event, err := codec.Decode(pending.event)
+		if err != nil {
+			return nil, nil, err
+		}
+		items[i] = QueuedEvent{ID: pending.id, Event: event}
+	}
+	letters := slices.Clone(saved.deadLetters)
+	for i := range letters {
+		event, err := codec.Decode(saved.lettered[i])
+		if err != nil {
+			return nil, nil, err
+		}
+		letters[i].event = event
+	}
+	return items, letters, nil
+}
+
+func (engine *Engine) observeCheckpoint(partitionID string, name string, now time.Time) {
+	engine.observe(EngineEvent{
+		Kind:        EnginePartitionCheckpointed,
+		PartitionID: partitionID,
+		VirtualTime: now,
+		Checkpoint:  name,
+	})
+}
+
+// Rewind puts a partition back to a checkpoint: the clock, the pending events,
+// the retry counts and dead-letter queue, and the state of every Stateful
+// component registered when it was taken. Pending and dead-lettered events
+// are fresh copies of those saved, under the handles they had at the
+// checkpoint. Checkpoints are kept, including those taken after the one
+// rewound to, and the partition's settings are left untouched. If a walk is in
+// progress, Rewind waits for it to finish.
+func (engine *Engine) Rewind(partitionID string, name string) error {
+	if partitionID == "SYSTEM" {
+		return fmt.Errorf("invalid operation: the SYSTEM partition follows wall-clock time and cannot be rewound")
+	}
+
+	state, err := engine.lockPartition(partitionID)
+	if err != nil {
+		return err
+	}
+	defer state.unlockWalk()
+
+	queue, provider, err := engine.getPartition(partitionID)
+	if err != nil {
+		return err
+	}
+	testClock, ok := provider.(*clock.TestClock)
+	if !ok {
+		return fmt.Errorf("partition %s is not a TestClock; only simulation partitions can be rewound", partitionID)
+	}
+
+	state.mu.Lock()
+	saved, ok := state.checkpoints[name]
+	if !ok {
+		state.mu.Unlock()
+		return fmt.Errorf("rewind partition %s to %q: %w", partitionID, name, ErrCheckpointNotFound)
+	}
+
+	items, letters, err := saved.decode(engine.getCodec())
+	if err == nil {
+		err = queue.load(items)
+	}
+	if err != nil {
+		state.mu.Unlock()
+		return fmt.Errorf("rewind partition %s to %q: %w", partitionID, name, err)
+	}
+	testClock.Set(saved.time)
+	for i, savedState := range saved.states {
+		state.stateful[i].RestoreState(savedState)
+	}
+	state.lastAdvance = nil
+	state.attempts = maps.Clone(saved.attempts)
+	state.deadLetters = letters
+	state.mu.Unlock()
+
+	engine.observe(EngineEvent{
+		Kind:        EnginePartitionRewound,
+		PartitionID: partitionID,
+		VirtualTime: saved.time,
+		Checkpoint:  name,
+	})
+	return nil
+}
+
+// Checkpoints lists a partition's checkpoints ordered by time, then by name.
+func (engine *Engine) Checkpoints(partitionID string) ([]CheckpointInfo, error) {
+	if _, _, err := engine.getPartition(partitionID); err != nil {
+		return nil, err
+	}
+
+	state := engine.state(partitionID)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	infos := make([]CheckpointInfo, 0, len(state.checkpoints))
+	for name, saved := range state.checkpoints {
+		infos = append(infos, CheckpointInfo{Name: name, Time: saved.time, Pending: len(saved.events)})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if !infos[i].Time.Equal(infos[j].Time) {
+			return infos[i].Time.Before(infos[j].Time)
+		}
+		return infos[i].Name < infos[j].Name
+	})
+	return infos, nil
+}
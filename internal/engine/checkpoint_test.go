@@ -0,0 +1,176 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aaryansingh-dev/hybrid-logical-time-go/internal/clock"
+)
+
+// ledger is a Stateful component counting the events it has seen
+type ledger struct {
+	seen []string
+}
+
+func (l *ledger) SaveState() any { return append([]string(nil), l.seen...) }
+func (l *ledger) RestoreState(state any) {
+	l.seen = append([]string(nil), state.([]string)...)
+}
+
+// bookedEvent adds its label to a ledger when it runs. The ledger is handed
+// in by the registry's factory, so the copies a checkpoint makes write to it
+// as well
+type bookedEvent struct {
+	persistentEvent
+	book *ledger
+}
+
+func (e *bookedEvent) Execute(tp clock.TimeProvider) []Event {
+	e.book.seen = append(e.book.seen, e.Label)
+	return nil
+}
+
+// parentEvent schedules a Child an hour after itself
+type parentEvent struct {
+	persistentEvent
+}
+
+func (e *parentEvent) Execute(tp clock.TimeProvider) []Event {
+	return []Event{&persistentEvent{At: tp.Now().Add(time.Hour), Label: "Child", Partition: e.Partition}}
+}
+
+func init() {
+	RegisterEventType("engine_test.parentEvent", func() Event { return &parentEvent{} })
+}
+
+func TestEngine_Rewind_RestoresClockQueueAndState(t *testing.T) {
+	book := &ledger{}
+	registry := NewRegistry()
+	registry.Register("engine_test.bookedEvent", func() Event { return &bookedEvent{book: book} })
+	registry.Register("engine.Checkpoint", func() Event { return &CheckpointEvent{} })
+
+	eng := NewEngine(nil)
+	eng.SetRegistry(registry)
+	id := "rewind_test"
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	eng.RegisterPartition(id, clock.NewTestClock(start))
+	if err := eng.RegisterStateful(id, book); err != nil {
+		t.Fatal(err)
+	}
+	booked := func(at time.Time, label string) Event {
+		return &bookedEvent{persistentEvent: persistentEvent{At: at, Label: label, Partition: id}, book: book}
+	}
+
+	eng.Schedule(booked(start.Add(time.Hour), "First"))
+	eng.Schedule(NewCheckpointEvent(start.Add(2*time.Hour), id, "day-0"))
+	second, _ := eng.Schedule(booked(start.Add(3*time.Hour), "Second"))
+
+	if err := eng.Advance(context.Background(), id, start.Add(24*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if len(book.seen) != 2 {
+		t.Fatalf("Expected both events to run, got %v", book.seen)
+	}
+
+	if err := eng.Rewind(id, "day-0"); err != nil {
+		t.Fatalf("Rewind failed: %v", err)
+	}
+
+	if now, _ := eng.GetPartitionTime(id); !now.Equal(start.Add(2 * time.Hour)) {
+		t.Errorf("Expected the clock back at the marker, got %s", now)
+	}
+	if len(book.seen) != 1 || book.seen[0] != "First" {
+		t.Errorf("Expected the ledger to be rewound to [First], got %v", book.seen)
+	}
+	pending, _ := eng.ListPendingEvents(id)
+	if len(pending) != 1 || pending[0].ID != second {
+		t.Fatalf("Expected Second to be pending again under its old handle, got %+v", pending)
+	}
+
+	// the rewound partition replays the same future
+	if err := eng.Advance(context.Background(), id, start.Add(24*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if len(book.seen) != 2 || book.seen[1] != "Second" {
+		t.Errorf("Expected Second to run again after the rewind, got %v", book.seen)
+	}
+}
+
+func TestEngine_Checkpoint_ManualAndErrors(t *testing.T) {
+	forEachStore(t, func(t *testing.T, factory QueueFactory) {
+		eng := NewEngine(nil)
+		eng.SetQueueFactory(factory)
+		id := "manual_checkpoint"
+		start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		eng.RegisterPartition(id, clock.NewTestClock(start))
+		eng.Schedule(&persistentEvent{At: start.Add(time.Hour), Label: "Pending", Partition: id})
+
+		if err := eng.Checkpoint(id, "start"); err != nil {
+			t.Fatal(err)
+		}
+		eng.Advance(context.Background(), id, start.Add(2*time.Hour))
+		eng.Checkpoint(id, "later")
+
+		infos, err := eng.Checkpoints(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(infos) != 2 || infos[0].Name != "start" || infos[0].Pending != 1 || infos[1].Pending != 0 {
+			t.Errorf("Unexpected checkpoints: %+v", infos)
+		}
+
+		if err := eng.Rewind(id, "missing"); !errors.Is(err, ErrCheckpointNotFound) {
+			t.Errorf("Expected ErrCheckpointNotFound, got %v", err)
+		}
+		if err := eng.Checkpoint("SYSTEM", "x"); err == nil {
+			t.Error("Expected SYSTEM checkpoints to be rejected")
+		}
+	})
+}
+
+func TestEngine_Rewind_CopiesPendingEvents(t *testing.T) {
+	forEachStore(t, func(t *testing.T, factory QueueFactory) {
+		eng := NewEngine(nil)
+		eng.SetQueueFactory(factory)
+		id := "copies"
+		start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		eng.RegisterPartition(id, clock.NewTestClock(start))
+
+		invoice := &persistentEvent{At: start.Add(time.Hour), Label: "Invoice", Partition: id}
+		eng.Schedule(invoice)
+		if err := eng.Checkpoint(id, "start"); err != nil {
+			t.Fatal(err)
+		}
+
+		// a change made through a handle after the checkpoint is not rewound into it,
+		// not even by an earlier rewind to the same checkpoint
+		for i := 0; i < 2; i++ {
+			invoice.Label = "Tampered"
+			if err := eng.Rewind(id, "start"); err != nil {
+				t.Fatal(err)
+			}
+			pending, _ := eng.ListPendingEvents(id)
+			if len(pending) != 1 || pending[0].Name != "Invoice" {
+				t.Fatalf("Expected the event as it was at the checkpoint, got %+v", pending)
+			}
+			invoice = eng.queues[id].Peek().(*persistentEvent)
+		}
+	})
+}
+
+func TestEngine_Checkpoint_RejectsUnregisteredEvents(t *testing.T) {
+	eng := NewEngine(nil)
+	id := "opaque"
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	eng.RegisterPartition(id, clock.NewTestClock(start))
+
+	eng.Schedule(&MockEvent{executionTime: start, name: "Unregistered", clockID: id})
+	if err := eng.Checkpoint(id, "start"); !errors.Is(err, ErrUnknownEventType) {
+		t.Errorf("Expected unregistered events to fail the checkpoint, got %v", err)
+	}
+	if infos, _ := eng.Checkpoints(id); len(infos) != 0 {
+		t.Errorf("Expected the failed checkpoint not to be saved, got %+v", infos)
+	}
+}
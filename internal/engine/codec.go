@@ -0,0 +1,110 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// EncodedEvent is the JSON envelope for a single event. Type selects the
+// factory in the Registry and Data holds the event's own JSON encoding.
+//
+// Time is the event's execution time. It is written by the codec so events
+// moved with Reschedule keep their new time, but may be omitted in
+// hand-written scenario files, in which case the event's own time is used.
+type EncodedEvent struct {
+	Type string          `json:"type"`
+	Time time.Time       `json:"time,omitempty"`
+	Data json.RawMessage `json:"data"`
+}
+
+// Codec converts engine.Event values to and from JSON using a Registry.
+// It is the foundation for snapshots, for sending events over an API, and for
+// loading them from scenario files.
+type Codec struct {
+	registry *Registry
+}
+
+// NewCodec returns a codec resolving types through the given registry.
+func NewCodec(registry *Registry) *Codec {
+	return &Codec{registry: registry}
+}
+
+// Encode wraps an event in its envelope.
+func (c *Codec) Encode(event Event) (EncodedEvent, error) {
+	name, err := c.registry.NameOf(event)
+	if err != nil {
+		return EncodedEvent{}, fmt.Errorf("encode event %s: %w", event.Name(), err)
+	}
+
+	// persist the original event; a Reschedule override is captured by Time
+	data, err := json.Marshal(unwrapEvent(event))
+	if err != nil {
+		return EncodedEvent{}, fmt.Errorf("encode event %s: %w", event.Name(), err)
+	}
+	return EncodedEvent{Type: name, Time: event.Time(), Data: data}, nil
+}
+
+// Decode rebuilds an event from its envelope.
+func (c *Codec) Decode(encoded EncodedEvent) (Event, error) {
+	event, err := c.registry.New(encoded.Type)
+	if err != nil {
+		return nil, fmt.Errorf("decode event: %w", err)
+	}
+	if err := json.Unmarshal(encoded.Data, event); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", encoded.Type, err)
+	}
+	if !encoded.Time.IsZero() && !event.Time().Equal(encoded.Time) {
+		event = withTime(event, encoded.Time)
+	}
+	return event, nil
+}
+
+// Marshal encodes a single event as a JSON envelope.
+func (c *Codec) Marshal(event Event) ([]byte, error) {
+	encoded, err := c.Encode(event)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(encoded)
+}
+
+// Unmarshal decodes a single event from a JSON envelope.
+func (c *Codec) Unmarshal(data []byte) (Event, error) {
+	var encoded EncodedEvent
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return nil, fmt.Errorf("decode event: %w", err)
+	}
+	return c.Decode(encoded)
+}
+
+// MarshalEvents encodes a list of events as a JSON array of envelopes.
+func (c *Codec) MarshalEvents(events []Event) ([]byte, error) {
+	encoded := make([]EncodedEvent, 0, len(events))
+	for _, event := range events {
+		e, err := c.Encode(event)
+		if err != nil {
+			return nil, err
+		}
+		encoded = append(encoded, e)
+	}
+	return json.Marshal(encoded)
+}
+
+// UnmarshalEvents decodes a JSON array of envelopes, such as a scenario file.
+func (c *Codec) UnmarshalEvents(data []byte) ([]Event, error) {
+	var encoded []EncodedEvent
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return nil, fmt.Errorf("decode events: %w", err)
+	}
+
+	events := make([]Event, 0, len(encoded))
+	for i, e := range encoded {
+		event, err := c.Decode(e)
+		if err != nil {
+			return nil, fmt.Errorf("event %d: %w", i, err)
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
@@ -0,0 +1,99 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRegistry_Register(t *testing.T) {
+	registry := NewRegistry()
+	factory := func() Event { return &persistentEvent{} }
+
+	if err := registry.Register("test.Event", factory); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Register("test.Event", func() Event { return &MockEvent{} }); err == nil {
+		t.Error("Expected duplicate name to be rejected")
+	}
+	if err := registry.Register("test.Alias", factory); err == nil {
+		t.Error("Expected duplicate Go type to be rejected")
+	}
+	if err := registry.Register("", factory); err == nil {
+		t.Error("Expected empty name to be rejected")
+	}
+
+	name, err := registry.NameOf(withTime(&persistentEvent{}, time.Now()))
+	if err != nil || name != "test.Event" {
+		t.Errorf("Expected rescheduled event to resolve to test.Event, got %q, %v", name, err)
+	}
+	if _, err := registry.New("test.Missing"); !errors.Is(err, ErrUnknownEventType) {
+		t.Errorf("Expected ErrUnknownEventType, got %v", err)
+	}
+}
+
+func TestCodec_RoundTrip(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register("test.Event", func() Event { return &persistentEvent{} })
+	codec := NewCodec(registry)
+
+	at := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	original := &persistentEvent{At: at, Label: "Renewal", Partition: "p"}
+
+	data, err := codec.Marshal(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := codec.Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *decoded.(*persistentEvent) != *original {
+		t.Errorf("Round trip changed the event: got %+v, want %+v", decoded, original)
+	}
+
+	// a rescheduled event keeps its new time
+	moved := withTime(original, at.Add(time.Hour))
+	data, _ = codec.Marshal(moved)
+	decoded, err = codec.Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Time().Equal(at.Add(time.Hour)) || decoded.Name() != "Renewal" {
+		t.Errorf("Expected Renewal at %v, got %s at %v", at.Add(time.Hour), decoded.Name(), decoded.Time())
+	}
+
+	if _, err := codec.Marshal(&MockEvent{}); !errors.Is(err, ErrUnknownEventType) {
+		t.Errorf("Expected ErrUnknownEventType for unregistered type, got %v", err)
+	}
+}
+
+func TestCodec_UnmarshalEvents_Scenario(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register("test.Event", func() Event { return &persistentEvent{} })
+	codec := NewCodec(registry)
+
+	// hand-written scenario files may leave out the envelope time
+	scenario := `[
+		{"type": "test.Event", "data": {"At": "2025-01-01T00:00:00Z", "Label": "First", "Partition": "p"}},
+		{"type": "test.Event", "time": "2025-02-01T00:00:00Z", "data": {"At": "2025-01-15T00:00:00Z", "Label": "Second", "Partition": "p"}}
+	]`
+
+	events, err := codec.UnmarshalEvents([]byte(scenario))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+	if !events[0].Time().Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected first event to keep its own time, got %v", events[0].Time())
+	}
+	if !events[1].Time().Equal(time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected envelope time to override, got %v", events[1].Time())
+	}
+
+	if _, err := codec.UnmarshalEvents([]byte(`[{"type": "test.Missing", "data": {}}]`)); !errors.Is(err, ErrUnknownEventType) {
+		t.Errorf("Expected ErrUnknownEventType, got %v", err)
+	}
+}
@@ -1,12 +1,13 @@
 package engine
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
 	"github.com/aaryansingh-dev/hybrid-logical-time-go/internal/clock"
-	"github.com/aaryansingh-dev/hybrid-logical-time-go/internal/context"
 )
 
 // Now moving to multi-tenancy which can handle multiple event queues and clocks.
@@ -17,9 +18,16 @@ type Engine struct {
 	queues      map[string]*EventQueue
 	clocks      map[string]clock.TimeProvider
 	systemQueue *EventQueue
+	partitions  map[string]*partitionState
 
-	mu   sync.RWMutex
-	diag Diagnostic
+	mu         sync.RWMutex
+	diag       Diagnostic
+	loopLimits LoopLimits
+	codec      *Codec
+	journal    *Journal
+
+	queueFactory       QueueFactory
+	defaultRetryPolicy RetryPolicy
 }
 
 // NewEngine initializes and returns a new simulation engine.
@@ -29,24 +37,49 @@ func NewEngine(diag Diagnostic) *Engine {
 	return &Engine{
 		queues:      make(map[string]*EventQueue),
 		clocks:      make(map[string]clock.TimeProvider),
+		partitions:  map[string]*partitionState{"SYSTEM": {walk: make(chan struct{}, 1)}},
 		diag:        diag,
 		systemQueue: NewEventQueue(),
+		loopLimits:  DefaultLoopLimits,
+		codec:       NewCodec(DefaultRegistry),
 	}
 }
 
 // RegisterPartition binds a partition ID to a specific TimeProvider.
 // This is used to setup independent sandboxes for testing or simulation.
-// If the partition's queue does not exist, it is initialized immediately.
-func (engine *Engine) RegisterPartition(partitionID string, timeProvider clock.TimeProvider) {
+// If the partition's queue does not exist, it is initialized immediately; a
+// queue created lazily by Schedule is kept, along with its events.
+// Registering an ID that already has a clock fails with ErrPartitionExists
+// rather than silently pairing the old events with a new clock.
+func (engine *Engine) RegisterPartition(partitionID string, timeProvider clock.TimeProvider) error {
+	if partitionID == "SYSTEM" {
+		return fmt.Errorf("invalid operation: SYSTEM is a reserved partition id")
+	}
+
 	// Useful for reigstering a new clock when a new simulation is started by the user.
 	engine.mu.Lock()
-	defer engine.mu.Unlock()
+	if _, exists := engine.clocks[partitionID]; exists {
+		engine.mu.Unlock()
+		return fmt.Errorf("register partition %s: %w", partitionID, ErrPartitionExists)
+	}
 
-	engine.clocks[partitionID] = timeProvider
 	if _, exists := engine.queues[partitionID]; !exists {
-		engine.queues[partitionID] = NewEventQueue()
+		queue, err := engine.newQueueLocked(partitionID)
+		if err != nil {
+			engine.mu.Unlock()
+			return err
+		}
+		engine.queues[partitionID] = queue
 	}
+	engine.clocks[partitionID] = timeProvider
+	engine.mu.Unlock()
 
+	event := EngineEvent{Kind: EnginePartitionRegistered, PartitionID: partitionID}
+	if timeProvider != nil {
+		event.VirtualTime = timeProvider.Now()
+	}
+	engine.observe(event)
+	return nil
 }
 
 // getPartition safely retrieves the queue and clock for a specific ID.
@@ -69,151 +102,361 @@ func (engine *Engine) getPartition(id string) (*EventQueue, clock.TimeProvider,
 	return queue, clock, nil
 }
 
-// Schedule adds an event to the appropriate partition's heap.
+// Schedule adds an event to the appropriate partition's heap and returns a
+// handle that can later be passed to Cancel or Reschedule.
 // If the partition does not exist, it is lazily registered using a double-check
 // lock pattern to handle concurrent initialization racing.
-func (engine *Engine) Schedule(event Event) {
+// Events dated before the partition's clock are handled according to the
+// partition's PastPolicy. With a journal attached, a SYSTEM event that cannot
+// be written to it is not scheduled either.
+func (engine *Engine) Schedule(event Event) (EventID, error) {
+	id := newEventID()
+
+	// SYSTEM events are journaled before they become visible, so a crash can
+	// never leave an acknowledged schedule out of the journal
+	var err error
+	if event.ClockID() == "SYSTEM" {
+		err = engine.journalSchedule(id, event)
+	}
+	if err == nil {
+		err = engine.schedule(id, 0, event)
+	}
+	if err != nil {
+		engine.observe(EngineEvent{
+			Kind:        EngineScheduleRejected,
+			PartitionID: event.ClockID(),
+			EventName:   event.Name(),
+			EventTime:   event.Time(),
+			Err:         err,
+		})
+		return 0, err
+	}
+	return id, nil
+}
+
+// schedule places an event under an already assigned handle. It is the part
+// of Schedule shared with causal scheduling, which journals differently.
+// parent is the handle of the executed event that produced this one, or zero.
+func (engine *Engine) schedule(id EventID, parent EventID, event Event) error {
 	partitionID := event.ClockID()
 
 	if partitionID == "SYSTEM" {
-		engine.systemQueue.PushEvent(event)
-		return
+		if err := engine.systemQueue.push(id, event); err != nil {
+			return err
+		}
+		engine.observeScheduled(id, parent, event, time.Now())
+		return nil
+	}
+
+	if engine.state(partitionID).isFrozen() {
+		return fmt.Errorf("schedule %s in partition %s: %w", event.Name(), partitionID, ErrPartitionFrozen)
 	}
 
 	// If it doesn't exist, we auto-register.
 	engine.mu.RLock()
 	queue, exists := engine.queues[partitionID]
+	provider, hasClock := engine.clocks[partitionID]
 	engine.mu.RUnlock()
 
+	// lazily registered partitions have no clock yet, so nothing is in their past
+	if hasClock && event.Time().Before(provider.Now()) {
+		switch engine.state(partitionID).getPastPolicy() {
+		case PastReject:
+			return fmt.Errorf("schedule %s at %s in partition %s (now %s): %w",
+				event.Name(),
+				event.Time().Format(time.RFC3339),
+				partitionID,
+				provider.Now().Format(time.RFC3339),
+				ErrEventInPast)
+		case PastClamp:
+			event = withTime(event, provider.Now())
+		case PastExecute:
+			if parent == 0 {
+				return engine.executePast(id, event)
+			}
+			// a causal event runs next in the walk that produced it, where
+			// loop protection applies, instead of recursing into execute
+			event = withTime(event, provider.Now())
+		}
+	}
+
 	// lazy registry pattern in case the queue was not made
 	if !exists {
+		created := false
 		engine.mu.Lock()
 		// double-check pattern to handle concurrent initialization racing.
 		if q, ok := engine.queues[partitionID]; ok {
 			queue = q
 		} else {
-			queue = NewEventQueue()
+			q, err := engine.newQueueLocked(partitionID)
+			if err != nil {
+				engine.mu.Unlock()
+				return err
+			}
+			queue = q
 			engine.queues[partitionID] = queue
+			created = true
 		}
 		engine.mu.Unlock()
+
+		if created {
+			engine.observe(EngineEvent{Kind: EnginePartitionRegistered, PartitionID: partitionID})
+		}
 	}
 
-	queue.PushEvent(event)
+	if err := queue.push(id, event); err != nil {
+		return err
+	}
+	var now time.Time
+	if hasClock {
+		now = provider.Now()
+	}
+	engine.observeScheduled(id, parent, event, now)
+	return nil
 }
 
-// StartRealTimeWorker launches a background goroutine that processes the SYSTEM partition.
-// It polls at the specified interval and executes events that have reached wall-clock time.
-// Future events generated by execution are automatically re-scheduled via the engine.
-func (engine *Engine) StartRealTimeWorker(interval time.Duration) {
-	ticker := time.NewTicker(interval)
-	realTime := clock.NewRealTimeProvider()
+// executePast runs an event scheduled in the past under PastExecute. It takes
+// the walk slot first, so the event never runs alongside a walk of the same
+// partition.
+func (engine *Engine) executePast(id EventID, event Event) error {
+	partitionID := event.ClockID()
+	state, err := engine.lockPartition(partitionID)
+	if err != nil {
+		return err
+	}
+	defer state.unlockWalk()
 
-	go func() {
-		for range ticker.C {
-			now := time.Now().UTC()
-			for {
-				next := engine.systemQueue.Peek()
+	// the partition may have been deleted or frozen while we waited
+	_, provider, err := engine.getPartition(partitionID)
+	if err != nil {
+		return err
+	}
+	if state.isFrozen() {
+		return fmt.Errorf("schedule %s in partition %s: %w", event.Name(), partitionID, ErrPartitionFrozen)
+	}
 
-				if next == nil || next.Time().After(now) {
-					break
-				}
+	engine.observeScheduled(id, 0, event, provider.Now())
+	_, err = engine.execute(partitionID, id, event, provider)
+	return err
+}
 
-				event := engine.systemQueue.PopEvent()
-				if engine.diag != nil {
-					engine.diag.OnEventExecute("SYSTEM", event.Name(), now)
-				}
+// observeScheduled reports an event that was queued, or is about to run
+// under PastExecute, as scheduled or, if it has a parent, as created.
+func (engine *Engine) observeScheduled(id EventID, parent EventID, event Event, now time.Time) {
+	kind := EngineEventScheduled
+	if parent != 0 {
+		kind = EngineEventCreated
+	}
+	engine.observe(EngineEvent{
+		Kind:        kind,
+		PartitionID: event.ClockID(),
+		EventID:     id,
+		ParentID:    parent,
+		EventName:   event.Name(),
+		EventTime:   event.Time(),
+		VirtualTime: now,
+	})
+}
 
-				futureEvents := event.Execute(realTime)
-				for _, futureEvent := range futureEvents {
-					engine.Schedule(futureEvent)
-					if engine.diag != nil {
-						engine.diag.OnEventCreated("SYSTEM", futureEvent.Name(), futureEvent.Time().UTC(), realTime.Now())
-					}
-				}
+// execute runs a single event against its partition's clock and schedules the
+// causal events it returns. Every child is attempted even if an earlier one
+// is rejected; the rejections are reported together.
+// It returns the handles of the children that were scheduled.
+func (engine *Engine) execute(partitionID string, id EventID, event Event, provider clock.TimeProvider) ([]EventID, error) {
+	if engine.diag != nil {
+		engine.diag.OnEventExecute(partitionID, event.Name(), provider.Now())
+	}
+	engine.observe(EngineEvent{
+		Kind:        EngineEventExecuted,
+		PartitionID: partitionID,
+		EventID:     id,
+		EventName:   event.Name(),
+		EventTime:   event.Time(),
+		VirtualTime: provider.Now(),
+	})
+
+	// Execute logic and handle "Causality" (chained events)
+	futureEvents, runErr := engine.runEvent(partitionID, id, event, provider)
+	if runErr != nil {
+		if err := engine.retryOrDeadLetter(partitionID, id, event, provider, runErr); err != nil {
+			engine.observeFailed(partitionID, id, event, provider, err)
+			return nil, err
+		}
+		return nil, nil
+	}
+	if _, ok := unwrapEvent(event).(Fallible); ok {
+		engine.state(partitionID).forgetAttempts(id)
+	}
+	ids := make([]EventID, len(futureEvents))
+	for i := range futureEvents {
+		ids[i] = newEventID()
+	}
 
-			}
+	if err := engine.journalExecute(partitionID, id, ids, futureEvents); err != nil {
+		engine.observeFailed(partitionID, id, event, provider, err)
+		return nil, err
+	}
+
+	var errs []error
+	var children []EventID
+	for i, futureEvent := range futureEvents {
+		if err := engine.schedule(ids[i], id, futureEvent); err != nil {
+			engine.observe(EngineEvent{
+				Kind:        EngineScheduleRejected,
+				PartitionID: futureEvent.ClockID(),
+				ParentID:    id,
+				EventName:   futureEvent.Name(),
+				EventTime:   futureEvent.Time(),
+				VirtualTime: provider.Now(),
+				Err:         err,
+			})
+			errs = append(errs, fmt.Errorf("event %s produced an invalid causal event: %w", event.Name(), err))
+			continue
 		}
-	}()
+		children = append(children, ids[i])
+		if engine.diag != nil {
+			engine.diag.OnEventCreated(partitionID, futureEvent.Name(), futureEvent.Time().UTC(), provider.Now())
+		}
+	}
 
+	err := errors.Join(errs...)
+	if err != nil {
+		engine.observeFailed(partitionID, id, event, provider, err)
+	}
+	return children, err
 }
 
-// Advance teleports a virtual partition to a target time.
-// It executes all intermediate events in strict chronological order, handling
-// any causal events that are generated during the process. This operation
-// is only permitted for non-SYSTEM partitions using a TestClock.
-func (engine *Engine) Advance(partitionID string, to time.Time, ctx *context.Context) error {
+func (engine *Engine) observeFailed(partitionID string, id EventID, event Event, provider clock.TimeProvider, err error) {
+	engine.observe(EngineEvent{
+		Kind:        EngineEventFailed,
+		PartitionID: partitionID,
+		EventID:     id,
+		EventName:   event.Name(),
+		EventTime:   event.Time(),
+		VirtualTime: provider.Now(),
+		Err:         err,
+	})
+}
 
-	if partitionID == "SYSTEM" {
-		return fmt.Errorf("invalid operation: the SYSTEM partition follows wall-clock time and cannot be advanced manually")
-	}
+// ErrEventNotFound is returned by Cancel and Reschedule when the handle does
+// not refer to a pending event, either because it already executed or it was
+// cancelled earlier.
+var ErrEventNotFound = errors.New("event not found")
 
-	// Resolve the specific queue and clock for this tenant
-	queue, provider, err := engine.getPartition(partitionID)
-	if err != nil {
-		return err
+// findQueue locates the queue currently holding the given event handle.
+// Handles are unique across partitions, so the first match is the only one.
+func (engine *Engine) findQueue(id EventID) (*EventQueue, bool) {
+	if engine.systemQueue.Contains(id) {
+		return engine.systemQueue, true
 	}
 
-	if engine.diag != nil {
-		engine.diag.OnAdvanceStart(partitionID, provider.Now(), to)
+	engine.mu.RLock()
+	defer engine.mu.RUnlock()
+
+	for _, queue := range engine.queues {
+		if queue.Contains(id) {
+			return queue, true
+		}
 	}
+	return nil, false
+}
 
-	testClock, ok := provider.(*clock.TestClock)
+// Cancel removes a pending event from its partition before it executes.
+// This models things like a customer cancelling during a trial: the
+// TrialEnded event simply never fires.
+func (engine *Engine) Cancel(id EventID) error {
+	queue, ok := engine.findQueue(id)
 	if !ok {
-		return fmt.Errorf("Partition %s is not a TestClock; manual time warping is only supported for simulation partitions", partitionID)
+		return fmt.Errorf("cancel event %d: %w", id, ErrEventNotFound)
 	}
 
-	for {
-		next := queue.Peek()
+	// the event may have been popped between the lookup and the removal
+	item, removed := queue.take(id)
+	if !removed {
+		return fmt.Errorf("cancel event %d: %w", id, ErrEventNotFound)
+	}
 
-		// EXIT CONDITION: If no more events exist OR the next event is
-		// scheduled for a time after our target, we jump to target and stop.
-		if next == nil || next.Time().After(to) {
-			testClock.Set(to)
-			if engine.diag != nil {
-				engine.diag.OnAdvanceFinish(partitionID, testClock.Now())
-			}
-			return nil
+	// a SYSTEM cancel is journaled only once it has happened, so the journal
+	// never says cancelled about an event that executed; if the record cannot
+	// be written, the event is put back
+	if queue == engine.systemQueue {
+		if err := engine.journalAppend(journalRecord{Op: journalCancel, ID: id}); err != nil {
+			return errors.Join(err, queue.restore(item))
 		}
+	}
 
-		// teleport to the next event
-		testClock.Set(next.Time())
-		event := queue.PopEvent()
+	event := item.Event
+	engine.observe(EngineEvent{
+		Kind:        EngineEventCancelled,
+		PartitionID: event.ClockID(),
+		EventID:     id,
+		EventName:   event.Name(),
+		EventTime:   event.Time(),
+	})
+	return nil
+}
 
-		if engine.diag != nil {
-			engine.diag.OnEventExecute(partitionID, event.Name(), testClock.Now())
-		}
+// Reschedule moves a pending event to a new execution time within its
+// partition. The event keeps its handle, so it can be moved or cancelled again.
+func (engine *Engine) Reschedule(id EventID, newTime time.Time) error {
+	queue, ok := engine.findQueue(id)
+	if !ok {
+		return fmt.Errorf("reschedule event %d: %w", id, ErrEventNotFound)
+	}
+
+	original, moved, err := queue.reschedule(id, newTime)
+	if err != nil {
+		return fmt.Errorf("reschedule event %d: %w", id, err)
+	}
+	if !moved {
+		return fmt.Errorf("reschedule event %d: %w", id, ErrEventNotFound)
+	}
 
-		// Execute logic and handle "Causality" (chained events)
-		futureEvents := event.Execute(testClock)
-		for _, futureEvent := range futureEvents {
-			engine.Schedule(futureEvent)
-			if engine.diag != nil {
-				engine.diag.OnEventCreated(partitionID, futureEvent.Name(), futureEvent.Time().UTC(), testClock.Now())
+	// journaled after the move, like a cancel; a failed write moves it back
+	if queue == engine.systemQueue {
+		if err := engine.journalAppend(journalRecord{Op: journalReschedule, ID: id, Time: &newTime}); err != nil {
+			if _, ok := queue.take(id); ok {
+				err = errors.Join(err, queue.restore(original))
 			}
+			return err
 		}
 	}
-}
-
-// GetStatus returns a snapshot of all registered partitions.
-// The resulting map contains human-readable status strings including current
-// logical time and pending event counts for each partition.
-func (engine *Engine) GetStatus() map[string]string {
-	engine.mu.RLock()
-	defer engine.mu.RUnlock()
 
-	status := make(map[string]string)
-	for id, provider := range engine.clocks {
-		queue := engine.queues[id]
-		status[id] = fmt.Sprintf("Time: %s | Pending Events: %d",
-			provider.Now().Format("2006-01-02 15:04:05"),
-			queue.Len())
-	}
+	event := original.Event
+	engine.observe(EngineEvent{
+		Kind:        EngineEventRescheduled,
+		PartitionID: event.ClockID(),
+		EventID:     id,
+		EventName:   event.Name(),
+		EventTime:   newTime,
+	})
+	return nil
+}
 
-	status["SYSTEM"] = fmt.Sprintf("Time: %s | Pending Events: %d",
-		time.Now().Format("2006-01-02 15:04:05"),
-		engine.systemQueue.Len())
+// Advance teleports a virtual partition to a target time.
+// It executes all intermediate events in strict chronological order, handling
+// any causal events that are generated during the process. This operation
+// is only permitted for non-SYSTEM partitions using a TestClock.
+//
+// Advances on the same partition are serialized: if a walk is already in
+// progress, Advance waits for it to finish and then continues from wherever
+// it left the clock. A target that is earlier than the partition's time at
+// that point is rejected, since virtual time never moves backwards.
+//
+// The walk checks ctx between events. If ctx is cancelled or its deadline
+// passes, Advance stops and returns an *AdvanceAbortedError describing how
+// far the partition got.
+func (engine *Engine) Advance(ctx context.Context, partitionID string, to time.Time) error {
+	return engine.AdvanceWithLimits(ctx, partitionID, to, AdvanceLimits{})
+}
 
-	return status
+// AdvanceWithLimits behaves like Advance, but additionally stops once the
+// walk exceeds the given event or wall-clock budget. Budget aborts are
+// reported the same way as cancellation, so the caller can resume by calling
+// Advance again with the same target.
+func (engine *Engine) AdvanceWithLimits(ctx context.Context, partitionID string, to time.Time, limits AdvanceLimits) error {
+	_, err := engine.walk(ctx, partitionID, walkSpec{to: to, land: true, limits: limits})
+	return err
 }
 
 // GetPartitionTime retrieves the current time for a specific partition.
@@ -1,6 +1,9 @@
 package engine
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"strings"
 	"sync"
 	"testing"
@@ -107,7 +110,7 @@ func TestEngine_Advance_CausalChain(t *testing.T) {
 	eng.Schedule(ev1)
 
 	target := start.Add(3 * time.Hour)
-	err := eng.Advance(id, target, nil)
+	err := eng.Advance(context.Background(), id, target)
 	if err != nil {
 		t.Fatalf("Advance failed: %v", err)
 	}
@@ -156,7 +159,7 @@ func TestEngine_SimulatedLifecycle_NoCyclicImport(t *testing.T) {
 	eng.Schedule(subEvent)
 
 	target := start.Add(20 * 24 * time.Hour)
-	err := eng.Advance(id, target, nil)
+	err := eng.Advance(context.Background(), id, target)
 	if err != nil {
 		t.Fatalf("Advance failed: %v", err)
 	}
@@ -198,7 +201,7 @@ func TestEngine_SimultaneousEvents(t *testing.T) {
 		eng.Schedule(&MockEvent{executionTime: execTime, name: "SameTime", clockID: id, onExecute: increment})
 	}
 
-	err := eng.Advance(id, execTime.Add(time.Minute), nil)
+	err := eng.Advance(context.Background(), id, execTime.Add(time.Minute))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -212,33 +215,926 @@ func TestEngine_Advance_Validation_Errors(t *testing.T) {
 	eng := NewEngine(nil)
 
 	// Test: Advancing non-existent partition
-	err := eng.Advance("GHOST", time.Now(), nil)
+	err := eng.Advance(context.Background(), "GHOST", time.Now())
 	if err == nil || !strings.Contains(err.Error(), "not registered") {
 		t.Errorf("Expected registration error, got: %v", err)
 	}
 
 	// Test: Advancing SYSTEM
-	err = eng.Advance("SYSTEM", time.Now(), nil)
+	err = eng.Advance(context.Background(), "SYSTEM", time.Now())
 	if err == nil || !strings.Contains(err.Error(), "invalid operation") {
 		t.Errorf("Expected system error, got: %v", err)
 	}
 }
 
 func TestEngine_StatusReporting(t *testing.T) {
+	forEachStore(t, func(t *testing.T, factory QueueFactory) {
+		eng := NewEngine(nil)
+		eng.SetQueueFactory(factory)
+		id := "status_check"
+		start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+		eng.RegisterPartition(id, clock.NewTestClock(start))
+
+		eng.Schedule(&persistentEvent{At: start.Add(time.Hour), Partition: id})
+
+		status := eng.GetStatus()
+		info, ok := status[id]
+		if !ok {
+			t.Fatal("Partition missing from status")
+		}
+
+		if !strings.Contains(info, "Pending Events: 1") {
+			t.Errorf("Status string incorrect: %s", info)
+		}
+
+		// the deprecated strings are rendered from the structured statuses
+		statuses := eng.Statuses()
+		if len(statuses) != len(status) {
+			t.Errorf("Expected %d structured statuses, got %d", len(status), len(statuses))
+		}
+		for _, s := range statuses {
+			if _, ok := status[s.ID]; !ok {
+				t.Errorf("Partition %s missing from GetStatus", s.ID)
+			}
+			if s.ID == id && (s.Pending != 1 || s.Provider != ProviderTestClock || !s.NextEventTime.Equal(start.Add(time.Hour))) {
+				t.Errorf("Unexpected structured status: %+v", s)
+			}
+		}
+	})
+}
+
+// PriorityMockEvent is a MockEvent with an explicit tie-break rank
+type PriorityMockEvent struct {
+	MockEvent
+	priority int
+}
+
+func (e *PriorityMockEvent) Priority() int { return e.priority }
+
+func TestEventQueue_SameTimestamp_InsertionOrder(t *testing.T) {
+	at := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	offsets := []time.Duration{2 * time.Hour, 0, time.Hour}
+
+	// interleave three instants so the sift operations keep reshaping the
+	// heap; a heap-layout dependent tie-break would scramble each instant
+	q := NewEventQueue()
+	want := make([][]string, len(offsets))
+	for i := 0; i < 90; i++ {
+		slot := i % len(offsets)
+		name := fmt.Sprintf("%s-%d", offsets[slot], i)
+		q.PushEvent(&MockEvent{executionTime: at.Add(offsets[slot]), name: name})
+		want[slot] = append(want[slot], name)
+	}
+
+	expected := append(append(want[1], want[2]...), want[0]...)
+	for i, name := range expected {
+		if got := q.PopEvent().Name(); got != name {
+			t.Fatalf("pop %d got %s, want %s", i, got, name)
+		}
+	}
+}
+
+func TestEventQueue_SameTimestamp_Priority(t *testing.T) {
+	at := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	q := NewEventQueue()
+
+	q.PushEvent(&MockEvent{executionTime: at, name: "Default1"})
+	q.PushEvent(&PriorityMockEvent{MockEvent: MockEvent{executionTime: at, name: "Low"}, priority: -1})
+	q.PushEvent(&PriorityMockEvent{MockEvent: MockEvent{executionTime: at, name: "High"}, priority: 10})
+	q.PushEvent(&MockEvent{executionTime: at, name: "Default2"})
+	q.PushEvent(&MockEvent{executionTime: at.Add(-time.Second), name: "Earlier"})
+
+	expected := []string{"Earlier", "High", "Default1", "Default2", "Low"}
+	for i, want := range expected {
+		if got := q.PopEvent().Name(); got != want {
+			t.Errorf("pop %d got %s, want %s", i, got, want)
+		}
+	}
+}
+
+func TestEventQueue_PopDue(t *testing.T) {
+	at := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	q := NewEventQueue()
+	first, _ := q.PushEvent(&MockEvent{executionTime: at, name: "First"})
+	q.PushEvent(&MockEvent{executionTime: at.Add(time.Hour), name: "Later"})
+
+	// the peeked head is cancelled before the pop, the next event is not due
+	q.Remove(first)
+	if item, ok := q.popDue(at); ok {
+		t.Fatalf("Expected nothing due at %s, popped %s", at, item.Event.Name())
+	}
+	if item, ok := q.popDue(time.Time{}); !ok || item.Event.Name() != "Later" {
+		t.Errorf("Expected an unbounded pop to return Later, got %+v", item)
+	}
+}
+
+func TestEngine_Cancel(t *testing.T) {
+	forEachStore(t, func(t *testing.T, factory QueueFactory) {
+		diag := &MockDiagnostic{}
+		eng := NewEngine(diag)
+		eng.SetQueueFactory(factory)
+		id := "cancel_tenant"
+		start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		eng.RegisterPartition(id, clock.NewTestClock(start))
+
+		eng.Schedule(&persistentEvent{At: start.Add(time.Hour), Label: "Kept", Partition: id})
+		trialEnd, _ := eng.Schedule(&persistentEvent{At: start.Add(2 * time.Hour), Label: "TrialEnded", Partition: id})
+
+		if err := eng.Cancel(trialEnd); err != nil {
+			t.Fatalf("Cancel failed: %v", err)
+		}
+		if err := eng.Cancel(trialEnd); !errors.Is(err, ErrEventNotFound) {
+			t.Errorf("Expected ErrEventNotFound on second cancel, got %v", err)
+		}
+
+		if err := eng.Advance(context.Background(), id, start.Add(3*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+
+		diag.mu.Lock()
+		defer diag.mu.Unlock()
+		if len(diag.eventsExecuted) != 1 || diag.eventsExecuted[0] != "Kept" {
+			t.Errorf("Expected only 'Kept' to execute, got %v", diag.eventsExecuted)
+		}
+	})
+}
+
+func TestEngine_Reschedule(t *testing.T) {
+	diag := &MockDiagnostic{}
+	eng := NewEngine(diag)
+	id := "reschedule_tenant"
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tc := clock.NewTestClock(start)
+	eng.RegisterPartition(id, tc)
+
+	var executedAt time.Time
+	retry, _ := eng.Schedule(&MockEvent{
+		executionTime: start.Add(time.Hour),
+		name:          "PaymentAttempt",
+		clockID:       id,
+		onExecute: func(tp clock.TimeProvider) []Event {
+			executedAt = tp.Now()
+			return nil
+		},
+	})
+	eng.Schedule(&MockEvent{executionTime: start.Add(2 * time.Hour), name: "Other", clockID: id})
+
+	// customer updated their card, push the retry out past the other event
+	newTime := start.Add(3 * time.Hour)
+	if err := eng.Reschedule(retry, newTime); err != nil {
+		t.Fatalf("Reschedule failed: %v", err)
+	}
+
+	if err := eng.Advance(context.Background(), id, start.Add(4*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	if !executedAt.Equal(newTime) {
+		t.Errorf("Rescheduled event ran at %v, want %v", executedAt, newTime)
+	}
+
+	diag.mu.Lock()
+	defer diag.mu.Unlock()
+	expected := []string{"Other", "PaymentAttempt"}
+	for i, name := range expected {
+		if i >= len(diag.eventsExecuted) || diag.eventsExecuted[i] != name {
+			t.Fatalf("Expected execution order %v, got %v", expected, diag.eventsExecuted)
+		}
+	}
+
+	if err := eng.Reschedule(retry, newTime); !errors.Is(err, ErrEventNotFound) {
+		t.Errorf("Expected ErrEventNotFound for executed event, got %v", err)
+	}
+}
+
+func TestEngine_ConcurrentAdvance_SamePartition(t *testing.T) {
+	diag := &MockDiagnostic{}
+	eng := NewEngine(diag)
+	id := "concurrent_tenant"
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tc := clock.NewTestClock(start)
+	eng.RegisterPartition(id, tc)
+
+	// each event chains into the next hour, so interleaved walks would
+	// execute the chain out of order or twice
+	var chain func(n int) func(tp clock.TimeProvider) []Event
+	chain = func(n int) func(tp clock.TimeProvider) []Event {
+		return func(tp clock.TimeProvider) []Event {
+			if n == 50 {
+				return nil
+			}
+			return []Event{&MockEvent{executionTime: tp.Now().Add(time.Hour), name: "Step", clockID: id, onExecute: chain(n + 1)}}
+		}
+	}
+	eng.Schedule(&MockEvent{executionTime: start.Add(time.Hour), name: "Step", clockID: id, onExecute: chain(1)})
+
+	target := start.Add(100 * time.Hour)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := eng.Advance(context.Background(), id, target); err != nil {
+				t.Errorf("Advance failed: %v", err)
+			}
+		}()
+	}
+
+	// readers racing the walkers
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if _, err := eng.GetPartitionTime(id); err != nil {
+					t.Errorf("GetPartitionTime failed: %v", err)
+				}
+				eng.GetStatus()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if !tc.Now().Equal(target) {
+		t.Errorf("Clock did not land on target. Got %v, want %v", tc.Now(), target)
+	}
+
+	diag.mu.Lock()
+	defer diag.mu.Unlock()
+	if len(diag.eventsExecuted) != 50 {
+		t.Errorf("Expected each of the 50 chained events to execute once, got %d", len(diag.eventsExecuted))
+	}
+}
+
+func TestEngine_Advance_RejectsPastTarget(t *testing.T) {
 	eng := NewEngine(nil)
-	id := "status_check"
+	id := "rewind_tenant"
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tc := clock.NewTestClock(start)
+	eng.RegisterPartition(id, tc)
+
+	err := eng.Advance(context.Background(), id, start.Add(-time.Hour))
+	if err == nil || !strings.Contains(err.Error(), "invalid target") {
+		t.Errorf("Expected invalid target error, got: %v", err)
+	}
+	if !tc.Now().Equal(start) {
+		t.Errorf("Clock moved backwards to %v", tc.Now())
+	}
+}
+
+func TestEngine_Advance_ContextCancelled(t *testing.T) {
+	diag := &MockDiagnostic{}
+	eng := NewEngine(diag)
+	id := "cancelled_walk"
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tc := clock.NewTestClock(start)
+	eng.RegisterPartition(id, tc)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// the second event cancels the walk from inside, as a caller would from another goroutine
+	eng.Schedule(&MockEvent{executionTime: start.Add(1 * time.Hour), name: "One", clockID: id})
+	eng.Schedule(&MockEvent{executionTime: start.Add(2 * time.Hour), name: "Two", clockID: id, onExecute: func(tp clock.TimeProvider) []Event {
+		cancel()
+		return nil
+	}})
+	eng.Schedule(&MockEvent{executionTime: start.Add(3 * time.Hour), name: "Three", clockID: id})
+
+	target := start.Add(10 * time.Hour)
+	err := eng.Advance(ctx, id, target)
+
+	var aborted *AdvanceAbortedError
+	if !errors.As(err, &aborted) {
+		t.Fatalf("Expected AdvanceAbortedError, got %v", err)
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected cause context.Canceled, got %v", aborted.Cause)
+	}
+	if aborted.Executed != 2 || !aborted.Reached.Equal(start.Add(2*time.Hour)) {
+		t.Errorf("Expected abort after 2 events at %v, got %d events at %v", start.Add(2*time.Hour), aborted.Executed, aborted.Reached)
+	}
+	if !tc.Now().Equal(aborted.Reached) {
+		t.Errorf("Clock %v does not match reported position %v", tc.Now(), aborted.Reached)
+	}
+
+	// resuming with a fresh context finishes the walk
+	if err := eng.Advance(context.Background(), id, target); err != nil {
+		t.Fatalf("Resume failed: %v", err)
+	}
+	diag.mu.Lock()
+	defer diag.mu.Unlock()
+	if len(diag.eventsExecuted) != 3 {
+		t.Errorf("Expected 3 events after resume, got %v", diag.eventsExecuted)
+	}
+}
+
+func TestEngine_AdvanceWithLimits_EventBudget(t *testing.T) {
+	forEachStore(t, func(t *testing.T, factory QueueFactory) {
+		eng := NewEngine(nil)
+		eng.SetQueueFactory(factory)
+		id := "budget_walk"
+		start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		tc := clock.NewTestClock(start)
+		eng.RegisterPartition(id, tc)
+
+		for i := 1; i <= 5; i++ {
+			eng.Schedule(&persistentEvent{At: start.Add(time.Duration(i) * time.Hour), Label: "Tick", Partition: id})
+		}
+
+		target := start.Add(10 * time.Hour)
+		err := eng.AdvanceWithLimits(context.Background(), id, target, AdvanceLimits{MaxEvents: 3})
+		if !errors.Is(err, ErrEventBudgetExceeded) {
+			t.Fatalf("Expected ErrEventBudgetExceeded, got %v", err)
+		}
+		if !tc.Now().Equal(start.Add(3 * time.Hour)) {
+			t.Errorf("Expected partition to stop at the third event, got %v", tc.Now())
+		}
+
+		// a budget that exactly covers the remaining work is not an abort
+		if err := eng.AdvanceWithLimits(context.Background(), id, target, AdvanceLimits{MaxEvents: 2}); err != nil {
+			t.Fatalf("Expected resume to finish within budget, got %v", err)
+		}
+		if !tc.Now().Equal(target) {
+			t.Errorf("Clock did not land on target. Got %v, want %v", tc.Now(), target)
+		}
+	})
+}
+
+func TestEngine_PastPolicy_Schedule(t *testing.T) {
 	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	past := start.Add(-time.Hour)
+
+	t.Run("reject", func(t *testing.T) {
+		eng := NewEngine(nil)
+		eng.RegisterPartition("p", clock.NewTestClock(start))
+		eng.SetPastPolicy("p", PastReject)
+
+		_, err := eng.Schedule(&MockEvent{executionTime: past, name: "Late", clockID: "p"})
+		if !errors.Is(err, ErrEventInPast) {
+			t.Errorf("Expected ErrEventInPast, got %v", err)
+		}
+		if eng.queues["p"].Len() != 0 {
+			t.Error("Rejected event should not be queued")
+		}
+	})
+
+	t.Run("clamp", func(t *testing.T) {
+		eng := NewEngine(nil)
+		eng.RegisterPartition("p", clock.NewTestClock(start))
+		eng.SetPastPolicy("p", PastClamp)
+
+		if _, err := eng.Schedule(&MockEvent{executionTime: past, name: "Late", clockID: "p"}); err != nil {
+			t.Fatal(err)
+		}
+		if got := eng.queues["p"].Peek().Time(); !got.Equal(start) {
+			t.Errorf("Expected event clamped to %v, got %v", start, got)
+		}
+	})
+
+	t.Run("execute", func(t *testing.T) {
+		eng := NewEngine(nil)
+		tc := clock.NewTestClock(start)
+		eng.RegisterPartition("p", tc)
+		eng.SetPastPolicy("p", PastExecute)
+
+		var ranAt time.Time
+		_, err := eng.Schedule(&MockEvent{executionTime: past, name: "Late", clockID: "p", onExecute: func(tp clock.TimeProvider) []Event {
+			ranAt = tp.Now()
+			return nil
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ranAt.Equal(start) {
+			t.Errorf("Expected event to execute immediately at %v, got %v", start, ranAt)
+		}
+		if eng.queues["p"].Len() != 0 {
+			t.Error("Immediately executed event should not be queued")
+		}
+	})
+}
+
+func TestEngine_PastPolicy_CausalEventFailsAdvance(t *testing.T) {
+	eng := NewEngine(nil)
+	id := "buggy_billing"
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
 	eng.RegisterPartition(id, clock.NewTestClock(start))
+	eng.SetPastPolicy(id, PastReject)
 
-	eng.Schedule(&MockEvent{executionTime: start.Add(time.Hour), clockID: id})
+	// a buggy event that schedules its follow-up a day before itself
+	eng.Schedule(&MockEvent{executionTime: start.Add(time.Hour), name: "TrialEnded", clockID: id, onExecute: func(tp clock.TimeProvider) []Event {
+		return []Event{&MockEvent{executionTime: tp.Now().Add(-24 * time.Hour), name: "InvoiceCreated", clockID: id}}
+	}})
 
-	status := eng.GetStatus()
-	info, ok := status[id]
-	if !ok {
-		t.Fatal("Partition missing from status")
+	err := eng.Advance(context.Background(), id, start.Add(2*time.Hour))
+	if !errors.Is(err, ErrEventInPast) {
+		t.Fatalf("Expected advance to fail with ErrEventInPast, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "TrialEnded") {
+		t.Errorf("Expected the error to name the offending event, got %v", err)
 	}
+}
+
+func TestEngine_PastPolicy_CausalChainIntoThePast(t *testing.T) {
+	eng := NewEngine(nil)
+	id := "rewinding_tenant"
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	eng.RegisterPartition(id, clock.NewTestClock(start))
+	eng.SetPastPolicy(id, PastExecute)
+
+	// every event schedules its successor a second before itself, forever
+	var back func(tp clock.TimeProvider) []Event
+	back = func(tp clock.TimeProvider) []Event {
+		return []Event{&MockEvent{executionTime: tp.Now().Add(-time.Second), name: "Back", clockID: id, onExecute: back}}
+	}
+	eng.Schedule(&MockEvent{executionTime: start.Add(time.Hour), name: "Back", clockID: id, onExecute: back})
+
+	var loop *CausalLoopError
+	if err := eng.Advance(context.Background(), id, start.Add(2*time.Hour)); !errors.As(err, &loop) {
+		t.Fatalf("Expected a CausalLoopError instead of unbounded recursion, got %v", err)
+	}
+	if !loop.At.Equal(start.Add(time.Hour)) {
+		t.Errorf("Expected the chain to be stuck at %s, got %s", start.Add(time.Hour), loop.At)
+	}
+}
+
+func TestEngine_PastPolicy_AllowNeverMovesClockBack(t *testing.T) {
+	eng := NewEngine(nil)
+	id := "late_tenant"
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tc := clock.NewTestClock(start)
+	eng.RegisterPartition(id, tc)
 
-	if !strings.Contains(info, "Pending Events: 1") {
-		t.Errorf("Status string incorrect: %s", info)
+	var ranAt time.Time
+	eng.Schedule(&MockEvent{executionTime: start.Add(-time.Hour), name: "Late", clockID: id, onExecute: func(tp clock.TimeProvider) []Event {
+		ranAt = tp.Now()
+		return nil
+	}})
+	if _, err := eng.Step(context.Background(), id, 1); err != nil {
+		t.Fatal(err)
 	}
+	if !ranAt.Equal(start) || !tc.Now().Equal(start) {
+		t.Errorf("Expected the late event to run at %s without moving the clock back, ran at %s, clock at %s", start, ranAt, tc.Now())
+	}
+}
+
+func TestEngine_Advance_DetectsZeroDelayLoop(t *testing.T) {
+	eng := NewEngine(nil)
+	id := "looping_tenant"
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	eng.RegisterPartition(id, clock.NewTestClock(start))
+
+	// Retry and Charge reschedule each other at the same instant forever
+	var charge, retry func(tp clock.TimeProvider) []Event
+	charge = func(tp clock.TimeProvider) []Event {
+		return []Event{&MockEvent{executionTime: tp.Now(), name: "Retry", clockID: id, onExecute: retry}}
+	}
+	retry = func(tp clock.TimeProvider) []Event {
+		return []Event{&MockEvent{executionTime: tp.Now(), name: "Charge", clockID: id, onExecute: charge}}
+	}
+	eng.Schedule(&MockEvent{executionTime: start.Add(time.Hour), name: "Charge", clockID: id, onExecute: charge})
+
+	done := make(chan error, 1)
+	go func() { done <- eng.Advance(context.Background(), id, start.Add(2*time.Hour)) }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Advance did not terminate on a zero-delay loop")
+	}
+
+	var loop *CausalLoopError
+	if !errors.As(err, &loop) {
+		t.Fatalf("Expected CausalLoopError, got %v", err)
+	}
+	if len(loop.Chain) != DefaultLoopLimits.MaxChainDepth+1 {
+		t.Errorf("Expected chain of %d events, got %d", DefaultLoopLimits.MaxChainDepth+1, len(loop.Chain))
+	}
+	if loop.Chain[0] != "Charge" || loop.Chain[1] != "Retry" || !loop.At.Equal(start.Add(time.Hour)) {
+		t.Errorf("Unexpected loop diagnostic: %v", loop)
+	}
+	if !strings.Contains(err.Error(), "Charge -> Retry") {
+		t.Errorf("Expected error to name the chain, got %v", err)
+	}
+}
+
+func TestEngine_Advance_EventStormLimits(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("per instant fan-out", func(t *testing.T) {
+		eng := NewEngine(nil)
+		eng.RegisterPartition("storm", clock.NewTestClock(start))
+		eng.SetLoopLimits(LoopLimits{MaxEventsPerInstant: 50})
+
+		// one event fans out into 100 same-instant children, no loop but a storm
+		eng.Schedule(&MockEvent{executionTime: start, name: "BatchRenewal", clockID: "storm", onExecute: func(tp clock.TimeProvider) []Event {
+			var batch []Event
+			for i := 0; i < 100; i++ {
+				batch = append(batch, &MockEvent{executionTime: tp.Now(), name: fmt.Sprintf("Renew-%d", i), clockID: "storm"})
+			}
+			return batch
+		}})
+
+		var loop *CausalLoopError
+		if err := eng.Advance(context.Background(), "storm", start.Add(time.Hour)); !errors.As(err, &loop) {
+			t.Fatalf("Expected CausalLoopError, got %v", err)
+		}
+		if strings.Join(loop.Chain, ",") != "BatchRenewal,Renew-49" {
+			t.Errorf("Expected chain BatchRenewal -> Renew-49, got %v", loop.Chain)
+		}
+		// the offender is left pending ahead of the renewals that never ran
+		if pending, _ := eng.ListPendingEvents("storm"); len(pending) != 51 || pending[0].Name != "Renew-49" {
+			t.Errorf("Expected Renew-49 to stay first of 51 pending events, got %d starting with %+v", len(pending), pending[0])
+		}
+	})
+
+	t.Run("per advance", func(t *testing.T) {
+		eng := NewEngine(nil)
+		eng.RegisterPartition("endless", clock.NewTestClock(start))
+		eng.SetLoopLimits(LoopLimits{MaxEventsPerAdvance: 10})
+
+		var tick func(tp clock.TimeProvider) []Event
+		tick = func(tp clock.TimeProvider) []Event {
+			return []Event{&MockEvent{executionTime: tp.Now().Add(time.Minute), name: "Tick", clockID: "endless", onExecute: tick}}
+		}
+		eng.Schedule(&MockEvent{executionTime: start, name: "Tick", clockID: "endless", onExecute: tick})
+
+		var loop *CausalLoopError
+		if err := eng.Advance(context.Background(), "endless", start.Add(time.Hour)); !errors.As(err, &loop) {
+			t.Fatalf("Expected CausalLoopError, got %v", err)
+		}
+	})
+
+	t.Run("offender lost", func(t *testing.T) {
+		eng := NewEngine(nil)
+		eng.SetQueueFactory(func(string) (QueueStore, error) {
+			return &noReinsertStore{HeapStore: NewHeapStore(), pushed: map[uint64]bool{}}, nil
+		})
+		eng.RegisterPartition("lost", clock.NewTestClock(start))
+		eng.SetLoopLimits(LoopLimits{MaxEventsPerAdvance: 1})
+
+		eng.Schedule(&MockEvent{executionTime: start, name: "First", clockID: "lost"})
+		eng.Schedule(&MockEvent{executionTime: start, name: "Second", clockID: "lost"})
+
+		var loop *CausalLoopError
+		err := eng.Advance(context.Background(), "lost", start.Add(time.Hour))
+		if !errors.As(err, &loop) || !errors.Is(err, errReinsert) {
+			t.Fatalf("Expected the loop error joined with the failed restore, got %v", err)
+		}
+		if pending, _ := eng.ListPendingEvents("lost"); len(pending) != 0 {
+			t.Errorf("Expected the offender to be gone, got %+v", pending)
+		}
+	})
+}
+
+var errReinsert = errors.New("re-insert refused")
+
+// noReinsertStore refuses to take back an event it has already handed out
+type noReinsertStore struct {
+	*HeapStore
+	pushed map[uint64]bool
+}
+
+func (s *noReinsertStore) Push(item QueuedEvent) error {
+	if s.pushed[item.Seq] {
+		return errReinsert
+	}
+	s.pushed[item.Seq] = true
+	return s.HeapStore.Push(item)
+}
+
+// newBillingChain registers a partition with a Subscription -> Trial -> Invoice
+// chain, where every invoice schedules the next one a month later
+func newBillingChain(diag Diagnostic, id string, start time.Time) (*Engine, *clock.TestClock) {
+	eng := NewEngine(diag)
+	tc := clock.NewTestClock(start)
+	eng.RegisterPartition(id, tc)
+
+	var invoice func(tp clock.TimeProvider) []Event
+	invoice = func(tp clock.TimeProvider) []Event {
+		return []Event{&MockEvent{executionTime: tp.Now().AddDate(0, 1, 0), name: "InvoiceCreated", clockID: id, onExecute: invoice}}
+	}
+	eng.Schedule(&MockEvent{executionTime: start, name: "SubscriptionCreated", clockID: id, onExecute: func(tp clock.TimeProvider) []Event {
+		return []Event{&MockEvent{executionTime: tp.Now().Add(14 * 24 * time.Hour), name: "TrialEnded", clockID: id, onExecute: func(tp clock.TimeProvider) []Event {
+			return []Event{&MockEvent{executionTime: tp.Now(), name: "InvoiceCreated", clockID: id, onExecute: invoice}}
+		}}}
+	}})
+	return eng, tc
+}
+
+func TestEngine_Step(t *testing.T) {
+	diag := &MockDiagnostic{}
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	eng, tc := newBillingChain(diag, "stepper", start)
+
+	n, err := eng.Step(context.Background(), "stepper", 1)
+	if err != nil || n != 1 {
+		t.Fatalf("Step(1) = %d, %v", n, err)
+	}
+	if !tc.Now().Equal(start) {
+		t.Errorf("Expected clock to stay at the executed event %v, got %v", start, tc.Now())
+	}
+
+	n, err = eng.Step(context.Background(), "stepper", 2)
+	if err != nil || n != 2 {
+		t.Fatalf("Step(2) = %d, %v", n, err)
+	}
+
+	diag.mu.Lock()
+	defer diag.mu.Unlock()
+	expected := []string{"SubscriptionCreated", "TrialEnded", "InvoiceCreated"}
+	if strings.Join(diag.eventsExecuted, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %v, got %v", expected, diag.eventsExecuted)
+	}
+}
+
+func TestEngine_AdvanceToNext(t *testing.T) {
+	diag := &MockDiagnostic{}
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	eng, _ := newBillingChain(diag, "jumper", start)
+	eng.Step(context.Background(), "jumper", 1)
+
+	// the trial end and the zero-delay invoice it creates share an instant
+	now, err := eng.AdvanceToNext(context.Background(), "jumper")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := start.Add(14 * 24 * time.Hour); !now.Equal(want) {
+		t.Errorf("Expected to land on the trial end %v, got %v", want, now)
+	}
+
+	diag.mu.Lock()
+	executed := len(diag.eventsExecuted)
+	diag.mu.Unlock()
+	if executed != 3 {
+		t.Errorf("Expected the whole instant to execute (3 events total), got %d", executed)
+	}
+
+	empty := NewEngine(nil)
+	empty.RegisterPartition("empty", clock.NewTestClock(start))
+	if _, err := empty.AdvanceToNext(context.Background(), "empty"); !errors.Is(err, ErrPartitionIdle) {
+		t.Errorf("Expected ErrPartitionIdle, got %v", err)
+	}
+}
+
+func TestEngine_RunUntil(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	eng, tc := newBillingChain(nil, "until", start)
+
+	invoices := 0
+	matched, err := eng.RunUntil(context.Background(), "until", func(e Event) bool {
+		if e.Name() == "InvoiceCreated" {
+			invoices++
+		}
+		return invoices == 3
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if matched == nil || matched.Name() != "InvoiceCreated" {
+		t.Fatalf("Expected to stop on an InvoiceCreated, got %v", matched)
+	}
+	if want := start.Add(14*24*time.Hour).AddDate(0, 2, 0); !tc.Now().Equal(want) {
+		t.Errorf("Expected third invoice at %v, got %v", want, tc.Now())
+	}
+}
+
+func TestEngine_RunUntil_RescheduledEvent(t *testing.T) {
+	forEachStore(t, func(t *testing.T, factory QueueFactory) {
+		start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		eng := NewEngine(nil)
+		eng.SetQueueFactory(factory)
+		eng.RegisterPartition("moved", clock.NewTestClock(start))
+
+		invoice := &persistentEvent{At: start.Add(time.Hour), Label: "InvoiceCreated", Partition: "moved"}
+		id, _ := eng.Schedule(invoice)
+		if err := eng.Reschedule(id, start.Add(2*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+
+		matched, err := eng.RunUntil(context.Background(), "moved", func(e Event) bool {
+			_, ok := e.(*persistentEvent)
+			return ok
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, ok := matched.(*persistentEvent); !ok || *got != *invoice {
+			t.Errorf("Expected the rescheduled event as it was scheduled, got %T", matched)
+		}
+	})
+}
+
+func TestEngine_RunUntilIdle(t *testing.T) {
+	diag := &MockDiagnostic{}
+	eng := NewEngine(diag)
+	id := "idle"
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	eng.RegisterPartition(id, clock.NewTestClock(start))
+	for i := 1; i <= 4; i++ {
+		eng.Schedule(&MockEvent{executionTime: start.AddDate(i, 0, 0), name: "Yearly", clockID: id})
+	}
+
+	n, err := eng.RunUntilIdle(context.Background(), id)
+	if err != nil || n != 4 {
+		t.Fatalf("RunUntilIdle = %d, %v", n, err)
+	}
+	if eng.queues[id].Len() != 0 {
+		t.Error("Expected the heap to be empty")
+	}
+
+	// a partition that never goes idle is stopped by the loop limits
+	endless, _ := newBillingChain(nil, "endless", start)
+	endless.SetLoopLimits(LoopLimits{MaxEventsPerAdvance: 100})
+	var loop *CausalLoopError
+	if _, err := endless.RunUntilIdle(context.Background(), "endless"); !errors.As(err, &loop) {
+		t.Errorf("Expected CausalLoopError for a never-ending chain, got %v", err)
+	}
+}
+
+func TestEngine_Status_Structured(t *testing.T) {
+	forEachStore(t, func(t *testing.T, factory QueueFactory) {
+		eng := NewEngine(nil)
+		eng.SetQueueFactory(factory)
+		id := "structured"
+		start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		eng.RegisterPartition(id, clock.NewTestClock(start))
+
+		eng.Schedule(&persistentEvent{At: start.Add(2 * time.Hour), Label: "Later", Partition: id})
+		eng.Schedule(&persistentEvent{At: start.Add(time.Hour), Label: "Sooner", Partition: id})
+		eng.Schedule(&persistentEvent{At: start, Label: "Lazy", Partition: "unregistered"})
+
+		status, err := eng.Status(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.Provider != ProviderTestClock || !status.Now.Equal(start) || status.Pending != 2 {
+			t.Errorf("Unexpected status: %+v", status)
+		}
+		if status.NextEventName != "Sooner" || !status.NextEventTime.Equal(start.Add(time.Hour)) {
+			t.Errorf("Expected next event Sooner at %v, got %s at %v", start.Add(time.Hour), status.NextEventName, status.NextEventTime)
+		}
+		if status.LastAdvance != nil {
+			t.Error("Expected no advance stats before the first walk")
+		}
+
+		eng.Advance(context.Background(), id, start.Add(90*time.Minute))
+		status, _ = eng.Status(id)
+		if status.LastAdvance == nil || status.LastAdvance.Executed != 1 || !status.LastAdvance.To.Equal(start.Add(90*time.Minute)) {
+			t.Errorf("Unexpected advance stats: %+v", status.LastAdvance)
+		}
+
+		var ids []string
+		for _, s := range eng.Statuses() {
+			ids = append(ids, s.ID)
+			if s.ID == "unregistered" && s.Provider != ProviderNone {
+				t.Errorf("Expected lazily created partition to have no provider, got %s", s.Provider)
+			}
+		}
+		if strings.Join(ids, ",") != "SYSTEM,structured,unregistered" {
+			t.Errorf("Expected sorted partitions including SYSTEM, got %v", ids)
+		}
+
+		if _, err := eng.Status("GHOST"); err == nil {
+			t.Error("Expected error for unknown partition")
+		}
+	})
+}
+
+func TestEngine_ListPendingEvents(t *testing.T) {
+	forEachStore(t, func(t *testing.T, factory QueueFactory) {
+		eng := NewEngine(nil)
+		eng.SetQueueFactory(factory)
+		id := "pending"
+		at := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		eng.RegisterPartition(id, clock.NewTestClock(at))
+
+		eng.Schedule(&persistentEvent{At: at.Add(time.Hour), Label: "C", Partition: id})
+		eng.Schedule(&persistentEvent{At: at, Label: "A", Partition: id})
+		eng.Schedule(&rankedEvent{persistentEvent: persistentEvent{At: at, Label: "Urgent", Partition: id}, Rank: 5})
+		eng.Schedule(&persistentEvent{At: at, Label: "B", Partition: id})
+
+		pending, err := eng.ListPendingEvents(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var names []string
+		for _, p := range pending {
+			names = append(names, p.Name)
+		}
+		if strings.Join(names, ",") != "Urgent,A,B,C" {
+			t.Errorf("Expected execution order Urgent,A,B,C, got %v", names)
+		}
+
+		// the listing must match what actually pops, and must not change the heap
+		if eng.queues[id].Len() != 4 {
+			t.Errorf("Listing modified the heap")
+		}
+		for i, p := range pending {
+			if got := eng.queues[id].PopEvent().Name(); got != p.Name {
+				t.Errorf("Pop %d got %s, listing said %s", i, got, p.Name)
+			}
+		}
+	})
+}
+
+func TestEngine_RegisterPartition_Twice(t *testing.T) {
+	eng := NewEngine(nil)
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// events scheduled before registration survive it
+	eng.Schedule(&MockEvent{executionTime: start, name: "Early", clockID: "p"})
+	if err := eng.RegisterPartition("p", clock.NewTestClock(start)); err != nil {
+		t.Fatal(err)
+	}
+	if eng.queues["p"].Len() != 1 {
+		t.Error("Expected the lazily scheduled event to be kept")
+	}
+
+	if err := eng.RegisterPartition("p", clock.NewTestClock(start.Add(time.Hour))); !errors.Is(err, ErrPartitionExists) {
+		t.Errorf("Expected ErrPartitionExists, got %v", err)
+	}
+	if err := eng.RegisterPartition("SYSTEM", clock.NewTestClock(start)); err == nil {
+		t.Error("Expected SYSTEM to be reserved")
+	}
+}
+
+func TestEngine_DeleteAndResetPartition(t *testing.T) {
+	forEachStore(t, func(t *testing.T, factory QueueFactory) {
+		eng := NewEngine(nil)
+		eng.SetQueueFactory(factory)
+		id := "lifecycle"
+		start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		tc := clock.NewTestClock(start)
+		eng.RegisterPartition(id, tc)
+		eng.Schedule(&persistentEvent{At: start.Add(time.Hour), Label: "A", Partition: id})
+		eng.Advance(context.Background(), id, start.Add(2*time.Hour))
+		eng.Schedule(&persistentEvent{At: start.Add(3 * time.Hour), Label: "B", Partition: id})
+
+		rewound := start.Add(-24 * time.Hour)
+		if err := eng.ResetPartition(id, rewound); err != nil {
+			t.Fatal(err)
+		}
+		status, _ := eng.Status(id)
+		if status.Pending != 0 || !status.Now.Equal(rewound) || status.LastAdvance != nil {
+			t.Errorf("Expected an empty partition at %v, got %+v", rewound, status)
+		}
+
+		if err := eng.DeletePartition(id); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := eng.Status(id); err == nil {
+			t.Error("Expected deleted partition to be gone")
+		}
+		if err := eng.Advance(context.Background(), id, start); err == nil {
+			t.Error("Expected advance on a deleted partition to fail")
+		}
+		if err := eng.DeletePartition(id); err == nil {
+			t.Error("Expected deleting twice to fail")
+		}
+
+		// the ID can be registered again from scratch
+		if err := eng.RegisterPartition(id, clock.NewTestClock(start)); err != nil {
+			t.Errorf("Expected re-registration after delete to succeed, got %v", err)
+		}
+	})
+}
+
+func TestEngine_FreezePartition(t *testing.T) {
+	forEachStore(t, func(t *testing.T, factory QueueFactory) {
+		eng := NewEngine(nil)
+		eng.SetQueueFactory(factory)
+		frozen, running := "frozen", "running"
+		start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		eng.RegisterPartition(frozen, clock.NewTestClock(start))
+		eng.RegisterPartition(running, clock.NewTestClock(start))
+
+		if err := eng.Freeze(frozen); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := eng.Schedule(&persistentEvent{At: start, Label: "A", Partition: frozen}); !errors.Is(err, ErrPartitionFrozen) {
+			t.Errorf("Expected Schedule to fail with ErrPartitionFrozen, got %v", err)
+		}
+		if err := eng.Advance(context.Background(), frozen, start.Add(time.Hour)); !errors.Is(err, ErrPartitionFrozen) {
+			t.Errorf("Expected Advance to fail with ErrPartitionFrozen, got %v", err)
+		}
+		if _, err := eng.Step(context.Background(), frozen, 1); !errors.Is(err, ErrPartitionFrozen) {
+			t.Errorf("Expected Step to fail with ErrPartitionFrozen, got %v", err)
+		}
+
+		// other partitions are unaffected
+		if err := eng.Advance(context.Background(), running, start.Add(time.Hour)); err != nil {
+			t.Errorf("Expected unfrozen partition to advance, got %v", err)
+		}
+
+		if err := eng.Unfreeze(frozen); err != nil {
+			t.Fatal(err)
+		}
+		if err := eng.Advance(context.Background(), frozen, start.Add(time.Hour)); err != nil {
+			t.Errorf("Expected advance after unfreeze to succeed, got %v", err)
+		}
+	})
 }
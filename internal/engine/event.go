@@ -2,17 +2,18 @@
 package engine
 
 import (
+	"sync/atomic"
 	"time"
 
 	"github.com/aaryansingh-dev/hybrid-logical-time-go/internal/clock"
 )
 
 // Event defines the behavioral contract for discrete actions within the simulation.
-// Implementations of this interface represent stateful business logic that 
+// Implementations of this interface represent stateful business logic that
 // executes at a specific point in logical or real time.
 type Event interface {
 	// Time returns the specific moment in time when this event is scheduled to run.
-	// In a simulation partition, the engine will "teleport" the clock to this 
+	// In a simulation partition, the engine will "teleport" the clock to this
 	// exact timestamp before execution.
 	Time() time.Time
 
@@ -25,8 +26,67 @@ type Event interface {
 	ClockID() string
 
 	// Execute runs the domain logic associated with the event.
-	// It accepts a TimeProvider to allow logic to be aware of the current 
-	// logical time. It returns a slice of future events to be scheduled, 
+	// It accepts a TimeProvider to allow logic to be aware of the current
+	// logical time. It returns a slice of future events to be scheduled,
 	// enabling the modeling of causal chains (e.g., TrialEnd triggering InvoiceCreated).
 	Execute(timeProvider clock.TimeProvider) []Event
-}
\ No newline at end of file
+}
+
+// Prioritized is an optional interface for events that need an explicit
+// tie-break rank. When several events are scheduled for the same instant,
+// the one with the higher Priority executes first. Events that do not
+// implement it are treated as priority 0, and equal priorities fall back to
+// insertion order, so same-timestamp execution is always reproducible.
+type Prioritized interface {
+	Priority() int
+}
+
+// EventID is the stable handle returned by Engine.Schedule. It identifies a
+// pending event across Cancel and Reschedule calls and is unique for the
+// lifetime of the process, regardless of which partition the event lives in.
+type EventID uint64
+
+// lastEventID backs newEventID. Zero is never handed out, so it can be used
+// as "no event".
+var lastEventID atomic.Uint64
+
+func newEventID() EventID {
+	return EventID(lastEventID.Add(1))
+}
+
+// reserveEventID makes sure newEventID never hands out id again, for handles
+// restored from outside the process such as a journal.
+func reserveEventID(id EventID) {
+	for {
+		last := lastEventID.Load()
+		if last >= uint64(id) || lastEventID.CompareAndSwap(last, uint64(id)) {
+			return
+		}
+	}
+}
+
+// rescheduledEvent overrides the execution time of an event that was moved
+// with Reschedule. Every other behaviour is delegated to the original event,
+// so Name, ClockID and Execute stay untouched.
+type rescheduledEvent struct {
+	Event
+	at time.Time
+}
+
+func (r *rescheduledEvent) Time() time.Time { return r.at }
+func (r *rescheduledEvent) Priority() int   { return priorityOf(r.Event) }
+
+// unwrapEvent returns the event as originally scheduled, without any
+// Reschedule override.
+func unwrapEvent(e Event) Event {
+	if r, ok := e.(*rescheduledEvent); ok {
+		return r.Event
+	}
+	return e
+}
+
+// withTime returns e moved to the given time. Rescheduling an already moved
+// event replaces the override instead of stacking wrappers.
+func withTime(e Event, at time.Time) Event {
+	return &rescheduledEvent{Event: unwrapEvent(e), at: at}
+}
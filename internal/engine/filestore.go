@@ -0,0 +1,321 @@
+package engine
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// FileStore is a QueueStore that keeps pending events in a file instead of
+// memory, for partitions holding millions of far-future events. Only a small
+// index entry per event (its handle, ordering keys and file position) stays in
+// memory; the event itself is encoded with a Codec on Push and decoded again
+// when it is read, so every event must be of a registered type.
+//
+// The file is scratch space, not a durable copy of the queue: it is not
+// reloaded on open and is removed by Close. Use Snapshot to persist a
+// partition.
+type FileStore struct {
+	path  string
+	file  *os.File
+	codec *Codec
+
+	index      fileIndex
+	byID       map[EventID]*fileEntry
+	size       int64 // end of the data written so far
+	garbage    int64 // bytes belonging to events no longer pending
+	compactErr error // the latest failed compaction, see CompactErr
+
+	// the head is peeked before every step of a walk, so the decoded event is
+	// kept until it changes
+	cachedID    EventID
+	cachedEvent Event
+}
+
+// compactMinSize is the file size below which garbage is never reclaimed.
+const compactMinSize = 1 << 20
+
+// fileEntry is the in-memory index entry for one event in a FileStore.
+type fileEntry struct {
+	id       EventID
+	at       time.Time
+	priority int
+	seq      uint64
+	offset   int64
+	length   int64
+	index    int // position in the heap
+}
+
+func (e *fileEntry) before(other *fileEntry) bool {
+	return executesBefore(e.at, e.priority, e.seq, other.at, other.priority, other.seq)
+}
+
+// fileIndex is a container/heap of index entries.
+type fileIndex []*fileEntry
+
+func (h fileIndex) Len() int           { return len(h) }
+func (h fileIndex) Less(i, j int) bool { return h[i].before(h[j]) }
+func (h fileIndex) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *fileIndex) Push(x interface{}) {
+	entry := x.(*fileEntry)
+	entry.index = len(*h)
+	*h = append(*h, entry)
+}
+
+func (h *fileIndex) Pop() interface{} {
+	old := *h
+	n := len(old)
+	entry := old[n-1]
+	old[n-1] = nil
+	entry.index = -1
+	*h = old[:n-1]
+	return entry
+}
+
+// OpenFileStore creates an empty store backed by the file at path, replacing
+// anything already there.
+func OpenFileStore(path string, codec *Codec) (*FileStore, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open queue file: %w", err)
+	}
+	return &FileStore{
+		path:  path,
+		file:  file,
+		codec: codec,
+		byID:  make(map[EventID]*fileEntry),
+	}, nil
+}
+
+// FileStoreFactory returns a QueueFactory that keeps every partition's queue
+// in its own file under dir.
+func FileStoreFactory(dir string, codec *Codec) QueueFactory {
+	return func(partitionID string) (QueueStore, error) {
+		return OpenFileStore(filepath.Join(dir, partitionID+".queue"), codec)
+	}
+}
+
+func (s *FileStore) Push(item QueuedEvent) error {
+	encoded, err := s.codec.Encode(item.Event)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(encoded)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n') // keeps the file readable when debugging
+
+	if _, err := s.file.WriteAt(data, s.size); err != nil {
+		return fmt.Errorf("write queue file: %w", err)
+	}
+
+	entry := &fileEntry{
+		id:       item.ID,
+		at:       item.Event.Time(),
+		priority: item.Priority,
+		seq:      item.Seq,
+		offset:   s.size,
+		length:   int64(len(data)),
+	}
+	s.size += entry.length
+	heap.Push(&s.index, entry)
+	s.byID[item.ID] = entry
+	return nil
+}
+
+func (s *FileStore) Pop() (QueuedEvent, bool, error) {
+	if len(s.index) == 0 {
+		return QueuedEvent{}, false, nil
+	}
+	item, err := s.read(s.index[0])
+	if err != nil {
+		return QueuedEvent{}, false, err
+	}
+	s.drop(heap.Pop(&s.index).(*fileEntry))
+	s.reclaim()
+	return item, true, nil
+}
+
+func (s *FileStore) Peek() (QueuedEvent, bool, error) {
+	if len(s.index) == 0 {
+		return QueuedEvent{}, false, nil
+	}
+	item, err := s.read(s.index[0])
+	if err != nil {
+		return QueuedEvent{}, false, err
+	}
+	return item, true, nil
+}
+
+func (s *FileStore) Remove(id EventID) (QueuedEvent, bool, error) {
+	entry, ok := s.byID[id]
+	if !ok {
+		return QueuedEvent{}, false, nil
+	}
+	item, err := s.read(entry)
+	if err != nil {
+		return QueuedEvent{}, false, err
+	}
+	heap.Remove(&s.index, entry.index)
+	s.drop(entry)
+	s.reclaim()
+	return item, true, nil
+}
+
+func (s *FileStore) Len() int {
+	return len(s.index)
+}
+
+func (s *FileStore) Contains(id EventID) bool {
+	_, ok := s.byID[id]
+	return ok
+}
+
+// Iterate decodes the events one at a time, so only the index is copied.
+func (s *FileStore) Iterate(fn func(QueuedEvent) bool) error {
+	entries := append(fileIndex(nil), s.index...)
+	sort.Slice(entries, func(i, j int) bool { return entries[i].before(entries[j]) })
+
+	for _, entry := range entries {
+		item, err := s.read(entry)
+		if err != nil {
+			return err
+		}
+		if !fn(item) {
+			break
+		}
+	}
+	return nil
+}
+
+func (s *FileStore) Clear() error {
+	if err := s.file.Truncate(0); err != nil {
+		return fmt.Errorf("clear queue file: %w", err)
+	}
+	s.index = nil
+	s.byID = make(map[EventID]*fileEntry)
+	s.size = 0
+	s.garbage = 0
+	s.compactErr = nil
+	s.cachedID, s.cachedEvent = 0, nil
+	return nil
+}
+
+// Close closes and removes the file.
+func (s *FileStore) Close() error {
+	err := s.file.Close()
+	if removeErr := os.Remove(s.path); err == nil {
+		err = removeErr
+	}
+	return err
+}
+
+// read decodes the event behind an index entry.
+func (s *FileStore) read(entry *fileEntry) (QueuedEvent, error) {
+	if entry.id == s.cachedID && s.cachedEvent != nil {
+		return s.item(entry, s.cachedEvent), nil
+	}
+
+	data := make([]byte, entry.length)
+	if _, err := s.file.ReadAt(data, entry.offset); err != nil {
+		return QueuedEvent{}, fmt.Errorf("read queue file: %w", err)
+	}
+	var encoded EncodedEvent
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return QueuedEvent{}, fmt.Errorf("read queue file: event %d: %w", entry.id, err)
+	}
+	event, err := s.codec.Decode(encoded)
+	if err != nil {
+		return QueuedEvent{}, err
+	}
+
+	if entry.index == 0 {
+		s.cachedID, s.cachedEvent = entry.id, event
+	}
+	return s.item(entry, event), nil
+}
+
+func (s *FileStore) item(entry *fileEntry, event Event) QueuedEvent {
+	return QueuedEvent{ID: entry.id, Event: event, Priority: entry.priority, Seq: entry.seq}
+}
+
+// drop forgets an entry that has left the heap.
+func (s *FileStore) drop(entry *fileEntry) {
+	delete(s.byID, entry.id)
+	s.garbage += entry.length
+	if entry.id == s.cachedID {
+		s.cachedID, s.cachedEvent = 0, nil
+	}
+}
+
+// CompactErr returns the error of the latest attempt to reclaim garbage from
+// the file, or nil if it succeeded. A failed compaction does not lose any
+// events; the file just keeps growing until a later attempt succeeds.
+func (s *FileStore) CompactErr() error {
+	return s.compactErr
+}
+
+// reclaim compacts the file after an event has left it. The event is gone
+// from the index either way, so a failure is not reported to the caller of
+// Pop or Remove; it is kept for CompactErr and retried on the next removal.
+func (s *FileStore) reclaim() {
+	s.compactErr = s.maybeCompact()
+}
+
+// maybeCompact rewrites the file once more than half of it is garbage.
+func (s *FileStore) maybeCompact() error {
+	if s.size < compactMinSize || s.garbage*2 < s.size {
+		return nil
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".compact-*")
+	if err != nil {
+		return fmt.Errorf("compact queue file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	// copy the live records in file order and remember where they moved to
+	entries := append(fileIndex(nil), s.index...)
+	sort.Slice(entries, func(i, j int) bool { return entries[i].offset < entries[j].offset })
+
+	writer := bufio.NewWriter(tmp)
+	offsets := make([]int64, len(entries))
+	var size int64
+	for i, entry := range entries {
+		if _, err := io.Copy(writer, io.NewSectionReader(s.file, entry.offset, entry.length)); err != nil {
+			tmp.Close()
+			return fmt.Errorf("compact queue file: %w", err)
+		}
+		offsets[i] = size
+		size += entry.length
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("compact queue file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		tmp.Close()
+		return fmt.Errorf("compact queue file: %w", err)
+	}
+
+	s.file.Close()
+	s.file = tmp
+	for i, entry := range entries {
+		entry.offset = offsets[i]
+	}
+	s.size = size
+	s.garbage = 0
+	return nil
+}
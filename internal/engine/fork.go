@@ -0,0 +1,123 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/aaryansingh-dev/hybrid-logical-time-go/internal/clock"
+)
+
+// Forkable is implemented by events that can be copied into another
+// partition. ForkTo returns the event as if it had been scheduled in
+// partitionID; the events it produces when executed must belong to that
+// partition as well, or the fork would leak them back into its source.
+type Forkable interface {
+	ForkTo(partitionID string) Event
+}
+
+// ForkPartition copies a partition's clock and pending events into a new
+// partition dst, which can then be advanced independently of src. This makes
+// "what if" comparisons possible from a single starting state, such as a
+// payment failing in one branch and succeeding in the other.
+//
+// Events are deep-copied through the engine's Codec and then moved with
+// ForkTo, so every pending event must be of a registered type implementing
+// Forkable. The copies keep their execution order but get new handles. The
+// fork inherits the PastPolicy and RetryPolicy of src, but not its
+// dead-letter queue; it always starts unfrozen, even if src is frozen. If a
+// walk is in progress on src, ForkPartition waits for it to finish so the
+// fork is always taken between events.
+func (engine *Engine) ForkPartition(src, dst string) error {
+	if src == "SYSTEM" || dst == "SYSTEM" {
+		return fmt.Errorf("invalid operation: the SYSTEM partition follows wall-clock time and cannot be forked")
+	}
+	if src == dst {
+		return fmt.Errorf("fork partition %s: %w", dst, ErrPartitionExists)
+	}
+
+	state, err := engine.lockPartition(src)
+	if err != nil {
+		return err
+	}
+	defer state.unlockWalk()
+
+	queue, provider, err := engine.getPartition(src)
+	if err != nil {
+		return err
+	}
+	if _, ok := provider.(*clock.TestClock); !ok {
+		return fmt.Errorf("partition %s is not a TestClock; only simulation partitions can be forked", src)
+	}
+
+	// copy everything before touching the engine, so a failed fork leaves no half-built partition
+	codec := engine.getCodec()
+	pending := queue.snapshot()
+	events := make([]Event, 0, len(pending))
+	for _, event := range pending {
+		forked, err := forkEvent(codec, event, dst)
+		if err != nil {
+			return fmt.Errorf("fork partition %s into %s: %w", src, dst, err)
+		}
+		events = append(events, forked)
+	}
+
+	engine.mu.Lock()
+	_, hasClock := engine.clocks[dst]
+	_, hasQueue := engine.queues[dst]
+	if hasClock || hasQueue {
+		engine.mu.Unlock()
+		return fmt.Errorf("fork partition %s: %w", dst, ErrPartitionExists)
+	}
+	forkQueue, err := engine.newQueueLocked(dst)
+	if err != nil {
+		engine.mu.Unlock()
+		return err
+	}
+	ids := make([]EventID, len(events))
+	for i, event := range events {
+		ids[i] = newEventID()
+		if err := forkQueue.push(ids[i], event); err != nil {
+			engine.mu.Unlock()
+			forkQueue.close()
+			return fmt.Errorf("fork partition %s into %s: %w", src, dst, err)
+		}
+	}
+	engine.queues[dst] = forkQueue
+	engine.clocks[dst] = clock.NewTestClock(provider.Now())
+	engine.mu.Unlock()
+
+	forkState := engine.state(dst)
+	forkState.mu.Lock()
+	forkState.pastPolicy = state.getPastPolicy()
+	forkState.retryPolicy = state.getRetryPolicy()
+	forkState.mu.Unlock()
+
+	engine.observeLoaded(dst, provider.Now(), ids, events)
+	return nil
+}
+
+// forkEvent returns an independent copy of event belonging to partitionID.
+func forkEvent(codec *Codec, event Event, partitionID string) (Event, error) {
+	encoded, err := codec.Encode(event)
+	if err != nil {
+		return nil, err
+	}
+	copied, err := codec.Decode(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	// the Reschedule override has to be re-applied around the moved event
+	original := unwrapEvent(copied)
+	forkable, ok := original.(Forkable)
+	if !ok {
+		return nil, fmt.Errorf("event %s does not implement Forkable", event.Name())
+	}
+	forked := forkable.ForkTo(partitionID)
+	if forked.ClockID() != partitionID {
+		return nil, fmt.Errorf("event %s forked into partition %s, not %s", event.Name(), forked.ClockID(), partitionID)
+	}
+	if !copied.Time().Equal(forked.Time()) {
+		forked = withTime(forked, copied.Time())
+	}
+	return forked, nil
+}
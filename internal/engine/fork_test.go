@@ -0,0 +1,85 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aaryansingh-dev/hybrid-logical-time-go/internal/clock"
+)
+
+func (e *persistentEvent) ForkTo(partitionID string) Event {
+	return &persistentEvent{At: e.At, Label: e.Label, Partition: partitionID}
+}
+
+func TestEngine_ForkPartition_CopiesClockAndQueue(t *testing.T) {
+	forEachStore(t, func(t *testing.T, factory QueueFactory) {
+		eng := NewEngine(nil)
+		eng.SetQueueFactory(factory)
+		start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+		eng.RegisterPartition("base", clock.NewTestClock(start))
+		eng.SetPastPolicy("base", PastReject)
+
+		eng.Schedule(&persistentEvent{At: start.Add(2 * time.Hour), Label: "Later", Partition: "base"})
+		moved, _ := eng.Schedule(&persistentEvent{At: start.Add(3 * time.Hour), Label: "Moved", Partition: "base"})
+		eng.Reschedule(moved, start.Add(time.Hour))
+
+		if err := eng.ForkPartition("base", "branch"); err != nil {
+			t.Fatalf("ForkPartition failed: %v", err)
+		}
+
+		pending, err := eng.ListPendingEvents("branch")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pending) != 2 || pending[0].Name != "Moved" || !pending[0].Time.Equal(start.Add(time.Hour)) {
+			t.Fatalf("Expected the fork to keep order and rescheduled times, got %+v", pending)
+		}
+		if pending[0].ID == moved {
+			t.Error("Expected forked events to get their own handles")
+		}
+
+		status, _ := eng.Status("branch")
+		if !status.Now.Equal(start) || status.Frozen {
+			t.Errorf("Expected an unfrozen fork at %s, got %+v", start, status)
+		}
+		if eng.state("branch").getPastPolicy() != PastReject {
+			t.Error("Expected the fork to inherit the PastPolicy")
+		}
+
+		// advancing the branch must leave the base untouched
+		if err := eng.Advance(context.Background(), "branch", start.Add(4*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+		if base, _ := eng.ListPendingEvents("base"); len(base) != 2 {
+			t.Errorf("Expected the base to keep 2 pending events, got %d", len(base))
+		}
+		if now, _ := eng.GetPartitionTime("base"); !now.Equal(start) {
+			t.Errorf("Expected the base clock to stay at %s, got %s", start, now)
+		}
+	})
+}
+
+func TestEngine_ForkPartition_Errors(t *testing.T) {
+	eng := NewEngine(nil)
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	eng.RegisterPartition("base", clock.NewTestClock(start))
+	eng.RegisterPartition("taken", clock.NewTestClock(start))
+
+	if err := eng.ForkPartition("base", "taken"); !errors.Is(err, ErrPartitionExists) {
+		t.Errorf("Expected ErrPartitionExists, got %v", err)
+	}
+	if err := eng.ForkPartition("missing", "branch"); err == nil {
+		t.Error("Expected an error forking an unknown partition")
+	}
+
+	// a MockEvent cannot be moved to another partition, so nothing is created
+	eng.Schedule(&MockEvent{executionTime: start, name: "Opaque", clockID: "base"})
+	if err := eng.ForkPartition("base", "branch"); err == nil {
+		t.Error("Expected an error forking an unregistered event")
+	}
+	if _, err := eng.Status("branch"); err == nil {
+		t.Error("Expected a failed fork to leave no partition behind")
+	}
+}
@@ -0,0 +1,367 @@
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Journal is an append-only, write-ahead log of the SYSTEM partition. Without
+// it the real-time queue lives only in memory and a restart silently drops
+// every scheduled production event.
+//
+// Every schedule and execution of a SYSTEM event is appended and fsynced
+// before it takes effect in memory. A cancel or reschedule is appended right
+// after it took effect, since only then is it known that the event had not
+// already been popped for execution; if the record cannot be written the
+// change is undone. Either way, every change acknowledged by a successful
+// return survives a crash. AttachJournal replays the log to rebuild the queue
+// after a restart.
+//
+// Delivery semantics: an execution is recorded only after Event.Execute has
+// returned, in a single record that also carries the SYSTEM events it
+// produced. If the process dies while an event is executing, or before that
+// record reaches the disk, the event is still pending after replay and runs
+// again; none of its causal events are duplicated, because they were never
+// recorded. Event side effects are therefore executed at least once, while the
+// queue itself is restored exactly. Events with external side effects should
+// be idempotent.
+type Journal struct {
+	path string
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+const (
+	journalSchedule   = "schedule"
+	journalExecute    = "execute"
+	journalCancel     = "cancel"
+	journalReschedule = "reschedule"
+)
+
+// journalRecord is one line of the journal.
+type journalRecord struct {
+	Op       string          `json:"op"`
+	ID       EventID         `json:"id"`
+	Event    *EncodedEvent   `json:"event,omitempty"`    // schedule
+	Time     *time.Time      `json:"time,omitempty"`     // reschedule
+	Children []journalRecord `json:"children,omitempty"` // execute: schedule records of the SYSTEM events it produced
+}
+
+// OpenJournal opens the journal at path, creating it if it does not exist.
+func OpenJournal(path string) (*Journal, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open journal: %w", err)
+	}
+	return &Journal{path: path, file: file}, nil
+}
+
+// Close closes the underlying file. The engine must not be used to change
+// the SYSTEM partition afterwards.
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.file.Close()
+}
+
+// append writes a record and waits for it to reach the disk.
+func (j *Journal) append(record journalRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("journal %s %d: %w", record.Op, record.ID, err)
+	}
+	line = append(line, '\n')
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if _, err := j.file.Seek(0, io.SeekEnd); err != nil {
+		return fmt.Errorf("journal %s %d: %w", record.Op, record.ID, err)
+	}
+	if _, err := j.file.Write(line); err != nil {
+		return fmt.Errorf("journal %s %d: %w", record.Op, record.ID, err)
+	}
+	if err := j.file.Sync(); err != nil {
+		return fmt.Errorf("journal %s %d: %w", record.Op, record.ID, err)
+	}
+	return nil
+}
+
+// read returns every complete record in the journal. A crash can leave a
+// partially written last line behind; it is cut off so that later appends
+// start on a clean line. Corruption anywhere else is reported as an error.
+func (j *Journal) read() ([]journalRecord, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if _, err := j.file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("read journal: %w", err)
+	}
+	data, err := io.ReadAll(j.file)
+	if err != nil {
+		return nil, fmt.Errorf("read journal: %w", err)
+	}
+
+	var records []journalRecord
+	good := 0 // length of the prefix made of complete, valid lines
+	for good < len(data) {
+		end := bytes.IndexByte(data[good:], '\n')
+		if end < 0 {
+			break // torn tail, no newline made it to disk
+		}
+		line := data[good : good+end]
+
+		var record journalRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			if good+end+1 == len(data) {
+				break // torn tail that happens to end in a newline
+			}
+			return nil, fmt.Errorf("read journal: corrupt record at byte %d: %w", good, err)
+		}
+		records = append(records, record)
+		good += end + 1
+	}
+
+	if good < len(data) {
+		if err := j.file.Truncate(int64(good)); err != nil {
+			return nil, fmt.Errorf("read journal: truncate torn tail: %w", err)
+		}
+	}
+	return records, nil
+}
+
+// compact replaces the journal with one schedule record per pending event.
+// The new file is written next to the old one and renamed over it, so a crash
+// during compaction leaves either the old or the new journal, never a mix.
+// The directory is synced after the rename so that the new journal is the one
+// found after a crash; temporary files left behind by an earlier crash are
+// removed first.
+func (j *Journal) compact(pending []journalRecord) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	dir := filepath.Dir(j.path)
+	stale, _ := filepath.Glob(filepath.Join(dir, filepath.Base(j.path)+".compact-*"))
+	for _, name := range stale {
+		os.Remove(name)
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(j.path)+".compact-*")
+	if err != nil {
+		return fmt.Errorf("compact journal: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	writer := bufio.NewWriter(tmp)
+	encoder := json.NewEncoder(writer)
+	for _, record := range pending {
+		if err := encoder.Encode(record); err != nil {
+			tmp.Close()
+			return fmt.Errorf("compact journal: %w", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("compact journal: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("compact journal: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), j.path); err != nil {
+		tmp.Close()
+		return fmt.Errorf("compact journal: %w", err)
+	}
+
+	j.file.Close()
+	j.file = tmp
+	if err := syncDir(dir); err != nil {
+		return fmt.Errorf("compact journal: %w", err)
+	}
+	return nil
+}
+
+// syncDir makes a rename within dir durable.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
+
+// replayJournal folds the journal into the set of SYSTEM events still pending,
+// in the order they were last scheduled or rescheduled.
+func replayJournal(records []journalRecord) []journalRecord {
+	var order []EventID
+	pending := make(map[EventID]journalRecord)
+
+	add := func(record journalRecord) {
+		pending[record.ID] = record
+		order = append(order, record.ID)
+	}
+
+	for _, record := range records {
+		switch record.Op {
+		case journalSchedule:
+			add(record)
+		case journalExecute:
+			delete(pending, record.ID)
+			for _, child := range record.Children {
+				add(child)
+			}
+		case journalCancel:
+			delete(pending, record.ID)
+		case journalReschedule:
+			if scheduled, ok := pending[record.ID]; ok && record.Time != nil {
+				event := *scheduled.Event
+				event.Time = *record.Time
+				scheduled.Event = &event
+				delete(pending, record.ID)
+				add(scheduled) // a reschedule counts as a fresh insertion for tie-breaking
+			}
+		}
+	}
+
+	// an ID can appear in order more than once after a reschedule, keep the last
+	last := make(map[EventID]int)
+	for i, id := range order {
+		last[id] = i
+	}
+	var result []journalRecord
+	for i, id := range order {
+		if record, ok := pending[id]; ok && last[id] == i {
+			result = append(result, record)
+		}
+	}
+	return result
+}
+
+// ErrJournalAttached is returned by AttachJournal if the engine already has one.
+var ErrJournalAttached = errors.New("journal already attached")
+
+// AttachJournal replays the journal into the SYSTEM partition and records
+// every later change to it. It must be called before any SYSTEM event is
+// scheduled and before the real-time worker starts. After replay the journal
+// is compacted down to the events still pending.
+//
+// Every SYSTEM event must be of a type registered in the engine's Registry,
+// since the journal stores events in their encoded form.
+func (engine *Engine) AttachJournal(journal *Journal) error {
+	engine.mu.Lock()
+	defer engine.mu.Unlock()
+
+	if engine.journal != nil {
+		return ErrJournalAttached
+	}
+	if engine.systemQueue.Len() > 0 {
+		return fmt.Errorf("attach journal: the SYSTEM partition already has pending events")
+	}
+
+	records, err := journal.read()
+	if err != nil {
+		return err
+	}
+	pending := replayJournal(records)
+
+	// decode everything before touching the queue, so a bad journal leaves it empty
+	events := make([]Event, len(pending))
+	for i, record := range pending {
+		event, err := engine.codec.Decode(*record.Event)
+		if err != nil {
+			return fmt.Errorf("attach journal: event %d: %w", record.ID, err)
+		}
+		events[i] = event
+	}
+
+	for i, record := range pending {
+		engine.systemQueue.push(record.ID, events[i])
+		reserveEventID(record.ID)
+	}
+
+	if err := journal.compact(pending); err != nil {
+		return err
+	}
+	engine.journal = journal
+	return nil
+}
+
+func (engine *Engine) getJournal() *Journal {
+	engine.mu.RLock()
+	defer engine.mu.RUnlock()
+
+	return engine.journal
+}
+
+// journalAppend writes a record if a journal is attached.
+func (engine *Engine) journalAppend(record journalRecord) error {
+	journal := engine.getJournal()
+	if journal == nil {
+		return nil
+	}
+	return journal.append(record)
+}
+
+// journalSchedule records a SYSTEM event about to be scheduled.
+func (engine *Engine) journalSchedule(id EventID, event Event) error {
+	if engine.getJournal() == nil {
+		return nil
+	}
+
+	record, err := engine.scheduleRecord(id, event)
+	if err != nil {
+		return err
+	}
+	return engine.journalAppend(record)
+}
+
+// journalExecute records an execution together with the SYSTEM events it
+// produced, before those events are scheduled. Executions in other partitions
+// are not journaled, but SYSTEM events they produce are.
+func (engine *Engine) journalExecute(partitionID string, id EventID, childIDs []EventID, children []Event) error {
+	if engine.getJournal() == nil {
+		return nil
+	}
+
+	var scheduled []journalRecord
+	for i, child := range children {
+		if child.ClockID() != "SYSTEM" {
+			continue
+		}
+		record, err := engine.scheduleRecord(childIDs[i], child)
+		if err != nil {
+			return err
+		}
+		scheduled = append(scheduled, record)
+	}
+
+	if partitionID == "SYSTEM" {
+		return engine.journalAppend(journalRecord{Op: journalExecute, ID: id, Children: scheduled})
+	}
+	for _, record := range scheduled {
+		if err := engine.journalAppend(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (engine *Engine) scheduleRecord(id EventID, event Event) (journalRecord, error) {
+	encoded, err := engine.getCodec().Encode(event)
+	if err != nil {
+		return journalRecord{}, fmt.Errorf("journal schedule %d: %w", id, err)
+	}
+	return journalRecord{Op: journalSchedule, ID: id, Event: &encoded}, nil
+}
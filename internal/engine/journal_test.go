@@ -0,0 +1,222 @@
+package engine
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aaryansingh-dev/hybrid-logical-time-go/internal/clock"
+)
+
+// chainEvent schedules one persistentEvent a minute after itself
+type chainEvent struct {
+	At    time.Time `json:"at"`
+	Label string    `json:"label"`
+}
+
+func (e *chainEvent) Time() time.Time { return e.At }
+func (e *chainEvent) Name() string    { return e.Label }
+func (e *chainEvent) ClockID() string { return "SYSTEM" }
+func (e *chainEvent) Execute(tp clock.TimeProvider) []Event {
+	return []Event{&persistentEvent{At: e.At.Add(time.Minute), Label: e.Label + " child", Partition: "SYSTEM"}}
+}
+
+func init() {
+	RegisterEventType("engine_test.chainEvent", func() Event { return &chainEvent{} })
+}
+
+// attachJournal opens the journal at path and attaches it to a fresh engine,
+// the way a restarted process would.
+func attachJournal(t *testing.T, path string) *Engine {
+	t.Helper()
+
+	journal, err := OpenJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { journal.Close() })
+
+	eng := NewEngine(nil)
+	if err := eng.AttachJournal(journal); err != nil {
+		t.Fatalf("AttachJournal failed: %v", err)
+	}
+	return eng
+}
+
+func pendingNames(eng *Engine) []string {
+	var names []string
+	for _, event := range eng.systemQueue.pending() {
+		names = append(names, event.Name)
+	}
+	return names
+}
+
+func TestJournal_ReplaysScheduleCancelReschedule(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "system.journal")
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	eng := attachJournal(t, path)
+	keep, _ := eng.Schedule(&persistentEvent{At: start.Add(time.Hour), Label: "Keep", Partition: "SYSTEM"})
+	drop, _ := eng.Schedule(&persistentEvent{At: start.Add(2 * time.Hour), Label: "Drop", Partition: "SYSTEM"})
+	eng.Schedule(&persistentEvent{At: start.Add(3 * time.Hour), Label: "Move", Partition: "SYSTEM"})
+	if err := eng.Cancel(drop); err != nil {
+		t.Fatal(err)
+	}
+	if err := eng.Reschedule(keep, start.Add(4*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	restarted := attachJournal(t, path)
+	got := pendingNames(restarted)
+	if len(got) != 2 || got[0] != "Move" || got[1] != "Keep" {
+		t.Fatalf("Expected [Move Keep] after replay, got %v", got)
+	}
+	if !restarted.systemQueue.Contains(keep) {
+		t.Errorf("Expected event %d to keep its handle across the restart", keep)
+	}
+
+	// handles handed out after the restart must not collide with replayed ones
+	id, _ := restarted.Schedule(&persistentEvent{At: start, Label: "New", Partition: "SYSTEM"})
+	if id <= keep || id <= drop {
+		t.Errorf("Expected a fresh handle above %d, got %d", drop, id)
+	}
+}
+
+func TestJournal_CrashDuringExecuteRedeliversEvent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "system.journal")
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	realTime := clock.NewTestClock(start.Add(time.Hour))
+
+	eng := attachJournal(t, path)
+	eng.Schedule(&chainEvent{At: start, Label: "Done"})
+	eng.Schedule(&chainEvent{At: start.Add(time.Second), Label: "InFlight"})
+
+	// the first event completes, the second is popped but the process dies
+	// before its execution is recorded
+	id, event := eng.systemQueue.popItem()
+	if _, err := eng.execute("SYSTEM", id, event, realTime); err != nil {
+		t.Fatal(err)
+	}
+	eng.systemQueue.popItem()
+
+	restarted := attachJournal(t, path)
+	got := pendingNames(restarted)
+	if len(got) != 2 || got[0] != "InFlight" || got[1] != "Done child" {
+		t.Fatalf("Expected [InFlight, Done child] after the crash, got %v", got)
+	}
+}
+
+func TestJournal_CrashDuringCancel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "system.journal")
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	eng := attachJournal(t, path)
+	inFlight, _ := eng.Schedule(&persistentEvent{At: start, Label: "InFlight", Partition: "SYSTEM"})
+	kept, _ := eng.Schedule(&persistentEvent{At: start.Add(time.Hour), Label: "Kept", Partition: "SYSTEM"})
+
+	// the worker pops the event between Cancel's lookup and its removal, so
+	// the cancel fails and must leave no record that would hide the event
+	// from the redelivery after the crash
+	heap := eng.systemQueue.store.(*HeapStore)
+	eng.systemQueue.store = &poppingStore{HeapStore: heap}
+	if err := eng.Cancel(inFlight); !errors.Is(err, ErrEventNotFound) {
+		t.Fatalf("Expected the in-flight event not to be cancellable, got %v", err)
+	}
+
+	// a cancel whose record cannot be written is undone
+	eng.getJournal().file.Close()
+	if err := eng.Cancel(kept); err == nil {
+		t.Fatal("Expected the cancel to fail without a journal")
+	}
+	if err := eng.Reschedule(kept, start.Add(2*time.Hour)); err == nil {
+		t.Fatal("Expected the reschedule to fail without a journal")
+	}
+	if pending := eng.systemQueue.pending(); len(pending) != 1 || pending[0].ID != kept || !pending[0].Time.Equal(start.Add(time.Hour)) {
+		t.Fatalf("Expected Kept to stay pending and unmoved, got %+v", pending)
+	}
+
+	restarted := attachJournal(t, path)
+	if got := pendingNames(restarted); len(got) != 2 || got[0] != "InFlight" || got[1] != "Kept" {
+		t.Fatalf("Expected [InFlight Kept] after the crash, got %v", got)
+	}
+}
+
+// poppingStore pops the earliest event right before the first removal, the
+// way the real-time worker can between a lookup and a removal
+type poppingStore struct {
+	*HeapStore
+	popped bool
+}
+
+func (s *poppingStore) Remove(id EventID) (QueuedEvent, bool, error) {
+	if !s.popped {
+		s.popped = true
+		s.HeapStore.Pop()
+	}
+	return s.HeapStore.Remove(id)
+}
+
+func TestJournal_CrashDuringCompaction(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "system.journal")
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	eng := attachJournal(t, path)
+	eng.Schedule(&persistentEvent{At: start, Label: "Written", Partition: "SYSTEM"})
+
+	// the process died while writing the compacted journal, before the rename
+	if err := os.WriteFile(path+".compact-123", []byte(`{"op":"schedule","id":1,"eve`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	restarted := attachJournal(t, path)
+	if got := pendingNames(restarted); len(got) != 1 || got[0] != "Written" {
+		t.Fatalf("Expected the old journal to be replayed, got %v", got)
+	}
+	if leftovers, _ := filepath.Glob(path + ".compact-*"); len(leftovers) != 0 {
+		t.Errorf("Expected the interrupted compaction to be cleaned up, got %v", leftovers)
+	}
+}
+
+func TestJournal_ToleratesTornTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "system.journal")
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	eng := attachJournal(t, path)
+	eng.Schedule(&persistentEvent{At: start, Label: "Written", Partition: "SYSTEM"})
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(`{"op":"schedule","id":99,"eve`)
+	file.Close()
+
+	restarted := attachJournal(t, path)
+	if got := pendingNames(restarted); len(got) != 1 || got[0] != "Written" {
+		t.Fatalf("Expected the torn record to be dropped, got %v", got)
+	}
+
+	// the journal keeps working after the tail was cut off
+	restarted.Schedule(&persistentEvent{At: start, Label: "After", Partition: "SYSTEM"})
+	if got := pendingNames(attachJournal(t, path)); len(got) != 2 {
+		t.Errorf("Expected 2 events after a second restart, got %v", got)
+	}
+}
+
+func TestJournal_AttachRejectsPendingSystemEvents(t *testing.T) {
+	eng := NewEngine(nil)
+	eng.Schedule(&persistentEvent{At: time.Now(), Label: "Early", Partition: "SYSTEM"})
+
+	journal, err := OpenJournal(filepath.Join(t.TempDir(), "system.journal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+
+	if err := eng.AttachJournal(journal); err == nil {
+		t.Error("Expected AttachJournal to fail with SYSTEM events already queued")
+	}
+}
@@ -0,0 +1,137 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aaryansingh-dev/hybrid-logical-time-go/internal/clock"
+)
+
+var (
+	// ErrPartitionExists is returned by RegisterPartition when the ID already
+	// has a clock. Use ResetPartition to start it over, or DeletePartition first.
+	ErrPartitionExists = errors.New("partition already registered")
+
+	// ErrPartitionFrozen is returned by Advance, the stepping APIs and
+	// Schedule while a partition is frozen.
+	ErrPartitionFrozen = errors.New("partition is frozen")
+)
+
+// DeletePartition drops a partition's queue and clock, discarding every
+// pending event. If a walk is in progress on the partition, DeletePartition
+// waits for it to finish first.
+func (engine *Engine) DeletePartition(partitionID string) error {
+	if partitionID == "SYSTEM" {
+		return fmt.Errorf("invalid operation: the SYSTEM partition cannot be deleted")
+	}
+
+	state, err := engine.lockPartition(partitionID)
+	if err != nil {
+		return err
+	}
+	defer state.unlockWalk()
+
+	engine.mu.Lock()
+	queue := engine.queues[partitionID]
+	delete(engine.queues, partitionID)
+	delete(engine.clocks, partitionID)
+	delete(engine.partitions, partitionID)
+	engine.mu.Unlock()
+
+	engine.observe(EngineEvent{Kind: EnginePartitionDeleted, PartitionID: partitionID})
+	return queue.close()
+}
+
+// ResetPartition clears a partition's heap and dead-letter queue and sets its
+// clock to t, which may be earlier than its current time. Settings such as
+// the PastPolicy, RetryPolicy and the frozen flag are kept. Only TestClock
+// partitions can be reset.
+func (engine *Engine) ResetPartition(partitionID string, t time.Time) error {
+	if partitionID == "SYSTEM" {
+		return fmt.Errorf("invalid operation: the SYSTEM partition follows wall-clock time and cannot be reset")
+	}
+
+	state, err := engine.lockPartition(partitionID)
+	if err != nil {
+		return err
+	}
+	defer state.unlockWalk()
+
+	queue, provider, err := engine.getPartition(partitionID)
+	if err != nil {
+		return err
+	}
+	testClock, ok := provider.(*clock.TestClock)
+	if !ok {
+		return fmt.Errorf("partition %s is not a TestClock; only simulation partitions can be reset", partitionID)
+	}
+
+	if err := queue.clear(); err != nil {
+		return fmt.Errorf("reset partition %s: %w", partitionID, err)
+	}
+	testClock.Set(t)
+
+	state.mu.Lock()
+	state.lastAdvance = nil
+	state.attempts = nil
+	state.deadLetters = nil
+	state.mu.Unlock()
+
+	engine.observe(EngineEvent{Kind: EnginePartitionReset, PartitionID: partitionID, VirtualTime: t})
+	return nil
+}
+
+// Freeze stops a partition from moving: Advance, the stepping APIs and
+// Schedule return ErrPartitionFrozen until Unfreeze is called. Pending events
+// and the clock are left untouched, so the partition can be inspected while
+// others keep running. If a walk is in progress, Freeze waits for it to
+// finish, so a walk is never cut off halfway.
+func (engine *Engine) Freeze(partitionID string) error {
+	return engine.setFrozen(partitionID, true)
+}
+
+// Unfreeze lifts a Freeze.
+func (engine *Engine) Unfreeze(partitionID string) error {
+	return engine.setFrozen(partitionID, false)
+}
+
+func (engine *Engine) setFrozen(partitionID string, frozen bool) error {
+	if partitionID == "SYSTEM" {
+		return fmt.Errorf("invalid operation: the SYSTEM partition follows wall-clock time and cannot be frozen")
+	}
+
+	state, err := engine.lockPartition(partitionID)
+	if err != nil {
+		return err
+	}
+	defer state.unlockWalk()
+
+	state.mu.Lock()
+	state.frozen = frozen
+	state.mu.Unlock()
+
+	kind := EnginePartitionUnfrozen
+	if frozen {
+		kind = EnginePartitionFrozen
+	}
+	engine.observe(EngineEvent{Kind: kind, PartitionID: partitionID})
+	return nil
+}
+
+// lockPartition acquires the walk slot of an existing partition, so lifecycle
+// changes never interleave with a causal walk.
+func (engine *Engine) lockPartition(partitionID string) (*partitionState, error) {
+	engine.mu.RLock()
+	_, exists := engine.queues[partitionID]
+	engine.mu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("partition %s not found", partitionID)
+	}
+
+	state := engine.state(partitionID)
+	state.lockWalk(context.Background())
+	return state, nil
+}
@@ -0,0 +1,244 @@
+package engine
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LineageNode is one event in a causal lineage graph.
+type LineageNode struct {
+	ID        EventID
+	Parent    EventID // the event whose Execute returned this one; zero for scheduled events
+	Partition string
+	Name      string
+	Time      time.Time // when the event executed, or is due if it has not yet
+	Executed  bool
+	Cancelled bool
+	Children  []EventID
+}
+
+// LineageRecorder is a Diagnostic that tracks which event created which, so
+// the causal chains of a partition can be reviewed as a graph. Scheduled
+// events are roots; every event returned by Execute is a child of the event
+// that returned it.
+//
+// It relies on the EngineObserver hooks, so it must be the engine's
+// Diagnostic or part of a MultiDiagnostic. Deleting or resetting a partition
+// forgets its graph, and rewinding it puts back the graph as it was at the
+// checkpoint; otherwise the graph grows for the lifetime of the recorder.
+type LineageRecorder struct {
+	mu          sync.Mutex
+	nodes       map[EventID]*LineageNode
+	byPartition map[string][]EventID // in the order the events were first seen
+	checkpoints map[lineageCheckpoint][]LineageNode
+}
+
+// lineageCheckpoint names a checkpoint of one partition.
+type lineageCheckpoint struct {
+	partition string
+	name      string
+}
+
+// NewLineageRecorder returns an empty LineageRecorder.
+func NewLineageRecorder() *LineageRecorder {
+	return &LineageRecorder{
+		nodes:       make(map[EventID]*LineageNode),
+		byPartition: make(map[string][]EventID),
+		checkpoints: make(map[lineageCheckpoint][]LineageNode),
+	}
+}
+
+func (l *LineageRecorder) OnAdvanceStart(id string, start, target time.Time)       {}
+func (l *LineageRecorder) OnAdvanceFinish(id string, current time.Time)            {}
+func (l *LineageRecorder) OnEventExecute(id string, eventName string, t time.Time) {}
+func (l *LineageRecorder) OnEventCreated(id string, eventName string, eventTime time.Time, currentTime time.Time) {
+}
+
+func (l *LineageRecorder) OnEngineEvent(event EngineEvent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	switch event.Kind {
+	case EngineEventScheduled, EngineEventCreated:
+		l.add(event)
+	case EngineEventExecuted:
+		// events replayed from a journal were never reported as scheduled
+		node := l.add(event)
+		node.Executed = true
+		node.Time = event.VirtualTime
+	case EngineEventCancelled:
+		if node, ok := l.nodes[event.EventID]; ok {
+			node.Cancelled = true
+		}
+	case EngineEventRescheduled:
+		if node, ok := l.nodes[event.EventID]; ok {
+			node.Time = event.EventTime
+		}
+	case EngineEventRetrying:
+		// a retry keeps its handle, so the node is pending again
+		if node, ok := l.nodes[event.EventID]; ok {
+			node.Executed = false
+			node.Time = event.EventTime
+		}
+	case EnginePartitionCheckpointed:
+		var saved []LineageNode
+		for _, id := range l.byPartition[event.PartitionID] {
+			saved = append(saved, copyNode(l.nodes[id]))
+		}
+		l.checkpoints[lineageCheckpoint{event.PartitionID, event.Checkpoint}] = saved
+	case EnginePartitionRewound:
+		// the restored events keep their handles, so the graph of the
+		// discarded timeline must go before they run again
+		l.forget(event.PartitionID)
+		for _, node := range l.checkpoints[lineageCheckpoint{event.PartitionID, event.Checkpoint}] {
+			restored := copyNode(&node)
+			l.nodes[restored.ID] = &restored
+			l.byPartition[restored.Partition] = append(l.byPartition[restored.Partition], restored.ID)
+		}
+	case EnginePartitionDeleted, EnginePartitionReset:
+		l.forget(event.PartitionID)
+		for key := range l.checkpoints {
+			if key.partition == event.PartitionID {
+				delete(l.checkpoints, key)
+			}
+		}
+	}
+}
+
+// forget drops a partition's graph. The caller must hold l.mu.
+func (l *LineageRecorder) forget(partitionID string) {
+	for _, id := range l.byPartition[partitionID] {
+		delete(l.nodes, id)
+	}
+	delete(l.byPartition, partitionID)
+}
+
+// add returns the node of an event, creating it on first sight. The caller
+// must hold l.mu.
+func (l *LineageRecorder) add(event EngineEvent) *LineageNode {
+	if node, ok := l.nodes[event.EventID]; ok {
+		return node
+	}
+	node := &LineageNode{
+		ID:        event.EventID,
+		Parent:    event.ParentID,
+		Partition: event.PartitionID,
+		Name:      event.EventName,
+		Time:      event.EventTime,
+	}
+	l.nodes[node.ID] = node
+	l.byPartition[node.Partition] = append(l.byPartition[node.Partition], node.ID)
+	if parent, ok := l.nodes[node.Parent]; ok {
+		parent.Children = append(parent.Children, node.ID)
+	}
+	return node
+}
+
+// Nodes returns a copy of a partition's lineage in the order the events were
+// first seen. Children created in other partitions are included, right after
+// their parent, so every edge of the partition has both ends.
+func (l *LineageRecorder) Nodes(partitionID string) []LineageNode {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var nodes []LineageNode
+	for _, id := range l.byPartition[partitionID] {
+		node := l.nodes[id]
+		nodes = append(nodes, copyNode(node))
+		for _, childID := range node.Children {
+			if child := l.nodes[childID]; child != nil && child.Partition != partitionID {
+				nodes = append(nodes, copyNode(child))
+			}
+		}
+	}
+	return nodes
+}
+
+func copyNode(node *LineageNode) LineageNode {
+	copied := *node
+	copied.Children = append([]EventID(nil), node.Children...)
+	return copied
+}
+
+// WriteDOT writes a partition's lineage as a Graphviz digraph. Nodes are
+// labelled with the event name and virtual time; pending events are dashed
+// and cancelled events dotted.
+func (l *LineageRecorder) WriteDOT(w io.Writer, partitionID string) error {
+	out := bufio.NewWriter(w)
+	nodes := l.Nodes(partitionID)
+
+	fmt.Fprintf(out, "digraph \"%s\" {\n", dotEscaper.Replace(partitionID))
+	fmt.Fprintln(out, "  rankdir=LR;")
+	fmt.Fprintln(out, "  node [shape=box];")
+	for _, node := range nodes {
+		style := ""
+		switch {
+		case node.Cancelled:
+			style = ", style=dotted"
+		case !node.Executed:
+			style = ", style=dashed"
+		}
+		fmt.Fprintf(out, "  e%d [label=\"%s\"%s];\n", node.ID, lineageLabel(node, partitionID, dotEscaper.Replace, `\n`), style)
+	}
+	for _, node := range nodes {
+		for _, child := range node.Children {
+			fmt.Fprintf(out, "  e%d -> e%d;\n", node.ID, child)
+		}
+	}
+	fmt.Fprintln(out, "}")
+	return out.Flush()
+}
+
+// WriteMermaid writes a partition's lineage as a Mermaid flowchart, which
+// renders inline in GitHub pull requests. Pending and cancelled events are
+// styled like in WriteDOT.
+func (l *LineageRecorder) WriteMermaid(w io.Writer, partitionID string) error {
+	out := bufio.NewWriter(w)
+	nodes := l.Nodes(partitionID)
+
+	fmt.Fprintln(out, "flowchart LR")
+	var pending, cancelled []string
+	for _, node := range nodes {
+		fmt.Fprintf(out, "  e%d[\"%s\"]\n", node.ID, lineageLabel(node, partitionID, mermaidEscaper.Replace, "<br/>"))
+		switch {
+		case node.Cancelled:
+			cancelled = append(cancelled, fmt.Sprintf("e%d", node.ID))
+		case !node.Executed:
+			pending = append(pending, fmt.Sprintf("e%d", node.ID))
+		}
+	}
+	for _, node := range nodes {
+		for _, child := range node.Children {
+			fmt.Fprintf(out, "  e%d --> e%d\n", node.ID, child)
+		}
+	}
+	if len(pending) > 0 {
+		fmt.Fprintln(out, "  classDef pending stroke-dasharray: 5 5")
+		fmt.Fprintf(out, "  class %s pending\n", strings.Join(pending, ","))
+	}
+	if len(cancelled) > 0 {
+		fmt.Fprintln(out, "  classDef cancelled stroke-dasharray: 2 2,color:gray")
+		fmt.Fprintf(out, "  class %s cancelled\n", strings.Join(cancelled, ","))
+	}
+	return out.Flush()
+}
+
+// lineageLabel names a node by event name and virtual time, adding the
+// partition for children that live in another one. Names are escaped for
+// the output format and lines joined with its line break.
+func lineageLabel(node LineageNode, partitionID string, escape func(string) string, newline string) string {
+	label := escape(node.Name) + newline + node.Time.Format(logTimeFormat)
+	if node.Partition != partitionID {
+		label += newline + "in " + escape(node.Partition)
+	}
+	return label
+}
+
+var (
+	dotEscaper     = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	mermaidEscaper = strings.NewReplacer(`"`, "#quot;")
+)
@@ -0,0 +1,123 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aaryansingh-dev/hybrid-logical-time-go/internal/clock"
+)
+
+// runLineage runs Parent -> Child and leaves a cancelled and a pending event
+func runLineage(t *testing.T) (*LineageRecorder, time.Time) {
+	t.Helper()
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	lineage := NewLineageRecorder()
+	eng := NewEngine(MultiDiagnostic(&MockDiagnostic{}, lineage))
+	eng.RegisterPartition("graph", clock.NewTestClock(start))
+
+	scheduleChain(eng, "graph", start)
+	dropped, _ := eng.Schedule(&MockEvent{executionTime: start.Add(time.Hour), name: "Dropped", clockID: "graph"})
+	eng.Schedule(&MockEvent{executionTime: start.AddDate(0, 1, 0), name: `Later "soon"`, clockID: "graph"})
+	eng.Cancel(dropped)
+	if err := eng.Advance(context.Background(), "graph", start.Add(2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	return lineage, start
+}
+
+func TestLineageRecorder_TracksParents(t *testing.T) {
+	lineage, start := runLineage(t)
+
+	nodes := lineage.Nodes("graph")
+	if len(nodes) != 4 {
+		t.Fatalf("Expected 4 nodes, got %+v", nodes)
+	}
+	byName := map[string]LineageNode{}
+	for _, node := range nodes {
+		byName[node.Name] = node
+	}
+
+	parent, child := byName["Parent"], byName["Child"]
+	if child.Parent != parent.ID || len(parent.Children) != 1 || parent.Children[0] != child.ID {
+		t.Errorf("Expected Child to descend from Parent, got %+v and %+v", parent, child)
+	}
+	if !child.Executed || !child.Time.Equal(start.Add(time.Hour)) {
+		t.Errorf("Expected Child executed at %s, got %+v", start.Add(time.Hour), child)
+	}
+	if !byName["Dropped"].Cancelled || byName[`Later "soon"`].Executed {
+		t.Errorf("Expected Dropped cancelled and Later pending, got %+v", byName)
+	}
+}
+
+func TestLineageRecorder_ExportsDOTAndMermaid(t *testing.T) {
+	lineage, _ := runLineage(t)
+	nodes := lineage.Nodes("graph")
+	parent, child := nodes[0], nodes[3] // scheduled first, created last
+
+	var dot bytes.Buffer
+	if err := lineage.WriteDOT(&dot, "graph"); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`digraph "graph" {`,
+		`[label="Parent\n2025-01-01 00:00:00"];`,
+		`[label="Later \"soon\"\n2025-02-01 00:00:00", style=dashed];`,
+		`style=dotted`,
+		fmt.Sprintf("e%d -> e%d;", parent.ID, child.ID),
+	} {
+		if !strings.Contains(dot.String(), want) {
+			t.Errorf("DOT output is missing %q:\n%s", want, dot.String())
+		}
+	}
+
+	var mermaid bytes.Buffer
+	if err := lineage.WriteMermaid(&mermaid, "graph"); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"flowchart LR",
+		`["Child<br/>2025-01-01 01:00:00"]`,
+		`["Later #quot;soon#quot;<br/>2025-02-01 00:00:00"]`,
+		fmt.Sprintf("e%d --> e%d", parent.ID, child.ID),
+		"pending",
+		"cancelled",
+	} {
+		if !strings.Contains(mermaid.String(), want) {
+			t.Errorf("Mermaid output is missing %q:\n%s", want, mermaid.String())
+		}
+	}
+}
+
+func TestLineageRecorder_RollsBackOnRewind(t *testing.T) {
+	forEachStore(t, func(t *testing.T, factory QueueFactory) {
+		start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		lineage := NewLineageRecorder()
+		eng := NewEngine(lineage)
+		eng.SetQueueFactory(factory)
+		eng.RegisterPartition("rewind", clock.NewTestClock(start))
+		eng.Schedule(&parentEvent{persistentEvent{At: start, Label: "Parent", Partition: "rewind"}})
+
+		if err := eng.Checkpoint("rewind", "start"); err != nil {
+			t.Fatal(err)
+		}
+		eng.Advance(context.Background(), "rewind", start.Add(2*time.Hour))
+		if err := eng.Rewind("rewind", "start"); err != nil {
+			t.Fatal(err)
+		}
+		if nodes := lineage.Nodes("rewind"); len(nodes) != 1 || nodes[0].Executed || len(nodes[0].Children) != 0 {
+			t.Fatalf("Expected only a pending Parent after the rewind, got %+v", nodes)
+		}
+
+		// running the same timeline again must not duplicate its edges
+		eng.Advance(context.Background(), "rewind", start.Add(2*time.Hour))
+		nodes := lineage.Nodes("rewind")
+		if len(nodes) != 2 || len(nodes[0].Children) != 1 || !nodes[1].Executed {
+			t.Errorf("Expected Parent with a single executed Child, got %+v", nodes)
+		}
+	})
+}
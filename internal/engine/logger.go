@@ -1,6 +1,7 @@
 package engine
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -15,6 +16,66 @@ type Diagnostic interface {
 	OnAdvanceFinish(id string, current time.Time)
 }
 
+// MultiDiagnostic returns a Diagnostic that forwards every hook to each of
+// the given diagnostics in order. Nil entries are skipped. Members that
+// implement an optional extension keep receiving it: OnEngineEvent goes to
+// every EngineObserver, and Verify asks every Verifier and joins their errors.
+func MultiDiagnostic(diags ...Diagnostic) Diagnostic {
+	multi := make(multiDiagnostic, 0, len(diags))
+	for _, diag := range diags {
+		if diag != nil {
+			multi = append(multi, diag)
+		}
+	}
+	return multi
+}
+
+type multiDiagnostic []Diagnostic
+
+func (m multiDiagnostic) OnAdvanceStart(id string, start, target time.Time) {
+	for _, diag := range m {
+		diag.OnAdvanceStart(id, start, target)
+	}
+}
+
+func (m multiDiagnostic) OnEventExecute(id string, eventName string, t time.Time) {
+	for _, diag := range m {
+		diag.OnEventExecute(id, eventName, t)
+	}
+}
+
+func (m multiDiagnostic) OnEventCreated(id string, eventName string, eventTime time.Time, currentTime time.Time) {
+	for _, diag := range m {
+		diag.OnEventCreated(id, eventName, eventTime, currentTime)
+	}
+}
+
+func (m multiDiagnostic) OnAdvanceFinish(id string, current time.Time) {
+	for _, diag := range m {
+		diag.OnAdvanceFinish(id, current)
+	}
+}
+
+func (m multiDiagnostic) OnEngineEvent(event EngineEvent) {
+	for _, diag := range m {
+		if observer, ok := diag.(EngineObserver); ok {
+			observer.OnEngineEvent(event)
+		}
+	}
+}
+
+func (m multiDiagnostic) Verify(partitionID string) error {
+	var errs []error
+	for _, diag := range m {
+		if verifier, ok := diag.(Verifier); ok {
+			if err := verifier.Verify(partitionID); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // ConsoleLogger implements the Diagnostic interface with formatted stdout output.
 // It provides a high-fidelity visual trace of the simulation's causal walks.
 type ConsoleLogger struct{}
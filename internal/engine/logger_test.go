@@ -0,0 +1,96 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/aaryansingh-dev/hybrid-logical-time-go/internal/clock"
+)
+
+// failingVerifier stops every walk it is asked about
+type failingVerifier struct {
+	MockDiagnostic
+	err error
+}
+
+func (f *failingVerifier) Verify(partitionID string) error { return f.err }
+
+// scheduleChain queues an event that produces one child an hour later
+func scheduleChain(eng *Engine, id string, start time.Time) {
+	eng.Schedule(&MockEvent{executionTime: start, name: "Parent", clockID: id, onExecute: func(tp clock.TimeProvider) []Event {
+		return []Event{&MockEvent{executionTime: tp.Now().Add(time.Hour), name: "Child", clockID: id}}
+	}})
+}
+
+func TestMultiDiagnostic_FansOutAndVerifies(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	first, second := &MockDiagnostic{}, &MockDiagnostic{}
+
+	eng := NewEngine(MultiDiagnostic(first, nil, second))
+	eng.RegisterPartition("multi", clock.NewTestClock(start))
+	scheduleChain(eng, "multi", start)
+	if err := eng.Advance(context.Background(), "multi", start.Add(2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	for _, diag := range []*MockDiagnostic{first, second} {
+		if len(diag.eventsExecuted) != 2 || len(diag.createdEvents) != 1 {
+			t.Errorf("Expected every sink to see 2 executions and 1 creation, got %v and %v",
+				diag.eventsExecuted, diag.createdEvents)
+		}
+	}
+
+	// a Verifier inside the fan-out still stops the walk
+	errBroken := errors.New("broken invariant")
+	eng = NewEngine(MultiDiagnostic(&MockDiagnostic{}, &failingVerifier{err: errBroken}))
+	eng.RegisterPartition("multi", clock.NewTestClock(start))
+	scheduleChain(eng, "multi", start)
+	if err := eng.Advance(context.Background(), "multi", start.Add(2*time.Hour)); !errors.Is(err, errBroken) {
+		t.Errorf("Expected the walk to fail with the verifier's error, got %v", err)
+	}
+}
+
+func TestSlogLogger_EmitsStructuredRecords(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+
+	eng := NewEngine(NewSlogLogger(slog.New(slog.NewJSONHandler(&buf, nil))))
+	eng.RegisterPartition("slog", clock.NewTestClock(start))
+	scheduleChain(eng, "slog", start)
+	if err := eng.Advance(context.Background(), "slog", start.Add(2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	var messages []string
+	decoder := json.NewDecoder(&buf)
+	for decoder.More() {
+		var record map[string]any
+		if err := decoder.Decode(&record); err != nil {
+			t.Fatal(err)
+		}
+		messages = append(messages, record["msg"].(string))
+
+		if record["partition_id"] != "slog" || record["virtual_time"] == nil || record["wall_time"] == nil {
+			t.Errorf("Record is missing partition, virtual or wall time: %v", record)
+		}
+		if record["msg"] == "event created" &&
+			(record["event_name"] != "Child" || record["scheduled_for"] != "2025-01-01T01:00:00Z") {
+			t.Errorf("Unexpected creation record: %v", record)
+		}
+	}
+
+	want := []string{"partition registered", "event scheduled",
+		"advance started", "event executed", "event created", "event executed", "advance finished"}
+	if len(messages) != len(want) {
+		t.Fatalf("Got records %v, want %v", messages, want)
+	}
+	for i := range want {
+		if messages[i] != want[i] {
+			t.Errorf("Record %d is %q, want %q", i, messages[i], want[i])
+		}
+	}
+}
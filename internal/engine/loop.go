@@ -0,0 +1,170 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LoopLimits protects walks against causal loops and event storms. A zero
+// value for any field disables that check.
+//
+// Unlike AdvanceLimits, which is a per-call budget the caller expects to hit
+// and resume from, tripping a LoopLimit means the event logic is broken, and
+// the walk fails with a *CausalLoopError.
+type LoopLimits struct {
+	// MaxChainDepth caps how many events may cause each other at a single
+	// virtual timestamp. An event returning a zero-delay child that returns a
+	// zero-delay child, and so on, makes no temporal progress; past this depth
+	// it is treated as a loop.
+	MaxChainDepth int
+
+	// MaxEventsPerInstant caps the total number of events executed at a single
+	// virtual timestamp, catching storms where one event fans out into
+	// unbounded zero-delay work.
+	MaxEventsPerInstant int
+
+	// MaxEventsPerAdvance caps the total number of events a single walk may
+	// execute, catching chains that do progress but never stop.
+	MaxEventsPerAdvance int
+}
+
+// DefaultLoopLimits are applied to every new Engine. They are generous
+// enough for batch renewals of large customer bases at midnight. A zero-delay
+// loop or storm trips the first two limits in a fraction of a second; a chain
+// that keeps moving forward runs at roughly a microsecond per event, so it
+// trips MaxEventsPerAdvance after about a second. Walks that legitimately
+// execute more events need higher limits via SetLoopLimits.
+var DefaultLoopLimits = LoopLimits{
+	MaxChainDepth:       1000,
+	MaxEventsPerInstant: 100_000,
+	MaxEventsPerAdvance: 1_000_000,
+}
+
+// SetLoopLimits replaces the loop protection applied to all partitions.
+// It takes effect for walks started after the call.
+func (engine *Engine) SetLoopLimits(limits LoopLimits) {
+	engine.mu.Lock()
+	defer engine.mu.Unlock()
+
+	engine.loopLimits = limits
+}
+
+func (engine *Engine) getLoopLimits() LoopLimits {
+	engine.mu.RLock()
+	defer engine.mu.RUnlock()
+
+	return engine.loopLimits
+}
+
+// CausalLoopError reports a walk stopped by LoopLimits. Chain names the events
+// that caused each other without time moving forward, oldest first, ending
+// with the event that tripped the limit.
+type CausalLoopError struct {
+	PartitionID string
+	At          time.Time // the virtual timestamp the walk was stuck at
+	Limit       string    // which limit was exceeded
+	Chain       []string
+}
+
+// chainPreview is how many trailing events Error prints from a long chain.
+const chainPreview = 8
+
+func (e *CausalLoopError) Error() string {
+	chain := e.Chain
+	prefix := ""
+	if len(chain) > chainPreview {
+		prefix = fmt.Sprintf("... %d more -> ", len(chain)-chainPreview)
+		chain = chain[len(chain)-chainPreview:]
+	}
+	return fmt.Sprintf("causal loop in partition %s at %s: %s exceeded, chain: %s%s",
+		e.PartitionID,
+		e.At.Format(time.RFC3339),
+		e.Limit,
+		prefix,
+		strings.Join(chain, " -> "))
+}
+
+// loopGuard tracks the lineage of events executed at the current instant
+// during a single walk. Lineage is only kept for one timestamp at a time:
+// as soon as the clock moves, progress has been made and the history is dropped.
+type loopGuard struct {
+	limits      LoopLimits
+	partitionID string
+
+	instant    time.Time
+	perInstant int
+	perAdvance int
+
+	parent map[EventID]EventID
+	depth  map[EventID]int
+	name   map[EventID]string
+}
+
+func newLoopGuard(partitionID string, limits LoopLimits) *loopGuard {
+	return &loopGuard{
+		limits:      limits,
+		partitionID: partitionID,
+		parent:      make(map[EventID]EventID),
+		depth:       make(map[EventID]int),
+		name:        make(map[EventID]string),
+	}
+}
+
+// admit is called before an event executes and fails if running it would
+// exceed a limit.
+func (g *loopGuard) admit(id EventID, event Event, at time.Time) error {
+	if !at.Equal(g.instant) {
+		g.instant = at
+		g.perInstant = 0
+		clear(g.parent)
+		clear(g.depth)
+		clear(g.name)
+	}
+
+	g.perInstant++
+	g.perAdvance++
+	g.name[id] = event.Name()
+	if _, ok := g.depth[id]; !ok {
+		g.depth[id] = 1
+	}
+
+	switch {
+	case g.limits.MaxChainDepth > 0 && g.depth[id] > g.limits.MaxChainDepth:
+		return g.fail(id, fmt.Sprintf("max chain depth %d", g.limits.MaxChainDepth))
+	case g.limits.MaxEventsPerInstant > 0 && g.perInstant > g.limits.MaxEventsPerInstant:
+		return g.fail(id, fmt.Sprintf("max %d events per instant", g.limits.MaxEventsPerInstant))
+	case g.limits.MaxEventsPerAdvance > 0 && g.perAdvance > g.limits.MaxEventsPerAdvance:
+		return g.fail(id, fmt.Sprintf("max %d events per advance", g.limits.MaxEventsPerAdvance))
+	}
+	return nil
+}
+
+// record links the causal events produced by an execution to their parent.
+// Children scheduled for a later time are recorded too, but dropped when the
+// clock reaches them, so only zero-delay chains accumulate depth.
+func (g *loopGuard) record(parent EventID, children []EventID) {
+	for _, child := range children {
+		g.parent[child] = parent
+		g.depth[child] = g.depth[parent] + 1
+	}
+}
+
+func (g *loopGuard) fail(id EventID, limit string) *CausalLoopError {
+	var chain []string
+	for current, ok := id, true; ok; current, ok = g.parent[current] {
+		chain = append(chain, g.name[current])
+	}
+
+	// walked from the offender back to the root, flip to oldest first
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+
+	return &CausalLoopError{
+		PartitionID: g.partitionID,
+		At:          g.instant,
+		Limit:       limit,
+		Chain:       chain,
+	}
+}
@@ -0,0 +1,121 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aaryansingh-dev/hybrid-logical-time-go/internal/clock"
+)
+
+// EngineEventKind names what happened inside the engine.
+type EngineEventKind string
+
+const (
+	// by RegisterPartition, a lazy Schedule, ForkPartition or Restore
+	EnginePartitionRegistered   EngineEventKind = "partition_registered"
+	EnginePartitionDeleted      EngineEventKind = "partition_deleted"
+	EnginePartitionReset        EngineEventKind = "partition_reset"
+	EnginePartitionCheckpointed EngineEventKind = "partition_checkpointed" // Checkpoint names the checkpoint taken
+	EnginePartitionRewound      EngineEventKind = "partition_rewound"
+	EnginePartitionFrozen       EngineEventKind = "partition_frozen"
+	EnginePartitionUnfrozen     EngineEventKind = "partition_unfrozen"
+
+	EngineEventScheduled    EngineEventKind = "event_scheduled"
+	EngineScheduleRejected  EngineEventKind = "schedule_rejected" // Err says why
+	EngineEventCancelled    EngineEventKind = "event_cancelled"
+	EngineEventRescheduled  EngineEventKind = "event_rescheduled" // EventTime is the new time
+	EngineEventExecuted     EngineEventKind = "event_executed"
+	EngineEventCreated      EngineEventKind = "event_created"       // a causal event, ParentID produced it
+	EngineEventPanicked     EngineEventKind = "event_panicked"      // Err is a *PanicError
+	EngineEventFailed       EngineEventKind = "event_failed"        // Err is the execute error
+	EngineEventRetrying     EngineEventKind = "event_retrying"      // a Fallible event failed with Err and runs again at EventTime
+	EngineEventDeadLettered EngineEventKind = "event_dead_lettered" // a Fallible event failed with Err and has no retries left
+
+	EngineAdvanceStarted  EngineEventKind = "advance_started"
+	EngineAdvanceFinished EngineEventKind = "advance_finished" // Err is set if the walk failed or aborted
+	EngineWorkerTick      EngineEventKind = "worker_tick"
+)
+
+// EngineEvent describes one thing that happened inside the engine. Fields
+// that do not apply to a kind are left at their zero value.
+type EngineEvent struct {
+	Kind        EngineEventKind
+	PartitionID string
+
+	EventID   EventID // the event the hook is about
+	ParentID  EventID // the executed event that produced EventID, for causal events
+	EventName string
+	EventTime time.Time // when EventID is due
+
+	VirtualTime time.Time // the partition's clock, when the hook has it at hand
+	Target      time.Time // where an advance was asked to go; zero for open-ended walks
+	WallTime    time.Time // when the hook fired
+
+	Checkpoint string // the checkpoint taken or rewound to
+	Executed   int    // events run by the finished advance or worker tick
+	Err        error
+}
+
+// EngineObserver is an optional extension of Diagnostic. A Diagnostic that
+// implements it is also told about partition lifecycle changes, scheduling,
+// cancellation, worker ticks and failing events, with event handles, so that
+// everything the engine did can be reconstructed. The four Diagnostic hooks
+// still fire as before; OnEngineEvent repeats them with more detail.
+//
+// OnEngineEvent is called synchronously, sometimes while a partition's walk
+// slot is held, so it must not call back into the engine.
+type EngineObserver interface {
+	OnEngineEvent(event EngineEvent)
+}
+
+// PanicError wraps the value an event panicked with.
+type PanicError struct {
+	Value any
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("event panicked: %v", e.Value)
+}
+
+// observe forwards an EngineEvent to the Diagnostic if it is an EngineObserver.
+func (engine *Engine) observe(event EngineEvent) {
+	observer, ok := engine.diag.(EngineObserver)
+	if !ok {
+		return
+	}
+	event.WallTime = time.Now()
+	observer.OnEngineEvent(event)
+}
+
+// observeLoaded reports a partition built in one go by ForkPartition or
+// Restore, followed by every event it was filled with.
+func (engine *Engine) observeLoaded(partitionID string, now time.Time, ids []EventID, events []Event) {
+	engine.observe(EngineEvent{Kind: EnginePartitionRegistered, PartitionID: partitionID, VirtualTime: now})
+	for i, event := range events {
+		engine.observeScheduled(ids[i], 0, event, now)
+	}
+}
+
+// runEvent executes an event, through TryExecute if it is Fallible. A panic
+// is reported to the observer before it continues to unwind: a walk passes it
+// on to its caller, the real-time worker recovers it.
+func (engine *Engine) runEvent(partitionID string, id EventID, event Event, provider clock.TimeProvider) ([]Event, error) {
+	defer func() {
+		if value := recover(); value != nil {
+			engine.observe(EngineEvent{
+				Kind:        EngineEventPanicked,
+				PartitionID: partitionID,
+				EventID:     id,
+				EventName:   event.Name(),
+				EventTime:   event.Time(),
+				VirtualTime: provider.Now(),
+				Err:         &PanicError{Value: value},
+			})
+			panic(value)
+		}
+	}()
+	if fallible, ok := unwrapEvent(event).(Fallible); ok {
+		return fallible.TryExecute(provider)
+	}
+	return event.Execute(provider), nil
+}
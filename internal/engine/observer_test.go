@@ -0,0 +1,131 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aaryansingh-dev/hybrid-logical-time-go/internal/clock"
+)
+
+// engineLog is a Diagnostic that also keeps every EngineEvent
+type engineLog struct {
+	MockDiagnostic
+	mu     sync.Mutex
+	events []EngineEvent
+}
+
+func (l *engineLog) OnEngineEvent(event EngineEvent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, event)
+}
+
+// take returns the events seen so far and forgets them
+func (l *engineLog) take() []EngineEvent {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	events := l.events
+	l.events = nil
+	return events
+}
+
+func kinds(events []EngineEvent) string {
+	var kinds []EngineEventKind
+	for _, event := range events {
+		kinds = append(kinds, event.Kind)
+	}
+	return fmt.Sprint(kinds)
+}
+
+func TestEngineObserver_ReportsLifecycleAndCausality(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	log := &engineLog{}
+	eng := NewEngine(log)
+
+	eng.RegisterPartition("obs", clock.NewTestClock(start))
+	scheduleChain(eng, "obs", start)
+	dropped, _ := eng.Schedule(&MockEvent{executionTime: start.Add(time.Hour), name: "Dropped", clockID: "obs"})
+	moved, _ := eng.Schedule(&MockEvent{executionTime: start.Add(time.Hour), name: "Moved", clockID: "obs"})
+	eng.Cancel(dropped)
+	eng.Reschedule(moved, start.Add(3*time.Hour))
+	eng.Freeze("obs")
+	if _, err := eng.Schedule(&MockEvent{executionTime: start, name: "Rejected", clockID: "obs"}); err == nil {
+		t.Fatal("Expected scheduling into a frozen partition to fail")
+	}
+	eng.Unfreeze("obs")
+
+	want := "[partition_registered event_scheduled event_scheduled event_scheduled event_cancelled event_rescheduled " +
+		"partition_frozen schedule_rejected partition_unfrozen]"
+	if got := kinds(log.take()); got != want {
+		t.Errorf("Got %s\nwant %s", got, want)
+	}
+
+	if err := eng.Advance(context.Background(), "obs", start.Add(2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	events := log.take()
+	want = "[advance_started event_executed event_created event_executed advance_finished]"
+	if got := kinds(events); got != want {
+		t.Fatalf("Got %s\nwant %s", got, want)
+	}
+	parent, child, childRun := events[1], events[2], events[3]
+	if child.ParentID != parent.EventID || childRun.EventID != child.EventID {
+		t.Errorf("Expected the child to link back to its parent, got parent %d, child %d (parent %d), executed %d",
+			parent.EventID, child.EventID, child.ParentID, childRun.EventID)
+	}
+	if finished := events[4]; finished.Executed != 2 || finished.Err != nil || !finished.VirtualTime.Equal(start.Add(2*time.Hour)) {
+		t.Errorf("Unexpected advance_finished: %+v", finished)
+	}
+
+	eng.DeletePartition("obs")
+	if got := kinds(log.take()); got != "[partition_deleted]" {
+		t.Errorf("Got %s, want [partition_deleted]", got)
+	}
+}
+
+func TestEngineObserver_ReportsPanicsAndWorkerTicks(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	log := &engineLog{}
+	eng := NewEngine(log)
+	eng.RegisterPartition("obs", clock.NewTestClock(now))
+	eng.Schedule(&MockEvent{executionTime: now, name: "Boom", clockID: "obs", onExecute: func(clock.TimeProvider) []Event {
+		panic("boom")
+	}})
+	log.take()
+
+	// the panic is reported, then carries on unwinding as before
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Expected the event's panic to propagate")
+			}
+		}()
+		eng.Advance(context.Background(), "obs", now.Add(time.Hour))
+	}()
+	var panicked *PanicError
+	events := log.take()
+	if len(events) < 3 || events[2].Kind != EngineEventPanicked || !errors.As(events[2].Err, &panicked) || panicked.Value != "boom" {
+		t.Errorf("Expected an event_panicked carrying the panic value, got %+v", events)
+	}
+
+	worker, ticker := newTestWorker(eng, StopAbandon, now)
+	eng.Schedule(&MockEvent{executionTime: now.Add(-time.Minute), name: "Due", clockID: "SYSTEM"})
+	worker.Start()
+	ticker.tick()
+	ticker.tick()
+	worker.Stop(context.Background())
+
+	var ticks []int
+	for _, event := range log.take() {
+		if event.Kind == EngineWorkerTick {
+			ticks = append(ticks, event.Executed)
+		}
+	}
+	if fmt.Sprint(ticks) != "[1 0]" {
+		t.Errorf("Expected two ticks executing 1 then 0 events, got %v", ticks)
+	}
+}
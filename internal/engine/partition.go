@@ -0,0 +1,113 @@
+package engine
+
+import (
+	"context"
+	"sync"
+)
+
+// partitionState holds the per-partition bookkeeping that lives next to the
+// queue and clock maps on Engine.
+type partitionState struct {
+	// walk serializes causal walks on the partition. A second Advance on the
+	// same partition waits here until the in-flight walk has finished, so
+	// events from two walks can never interleave. It is a one-slot semaphore
+	// rather than a sync.Mutex so that waiting can be abandoned via a context.
+	walk chan struct{}
+
+	mu          sync.Mutex // guards the fields below
+	pastPolicy  PastPolicy
+	lastAdvance *AdvanceStats
+	frozen      bool
+	checkpoints map[string]*checkpoint
+	stateful    []Stateful
+	retryPolicy RetryPolicy
+	attempts    map[EventID]int // failed attempts of Fallible events still being retried
+	deadLetters []DeadLetter
+}
+
+// isFrozen reports whether the partition has been frozen.
+func (state *partitionState) isFrozen() bool {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	return state.frozen
+}
+
+// getPastPolicy returns the partition's configured PastPolicy.
+func (state *partitionState) getPastPolicy() PastPolicy {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	return state.pastPolicy
+}
+
+// getRetryPolicy returns the partition's configured RetryPolicy.
+func (state *partitionState) getRetryPolicy() RetryPolicy {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	return state.retryPolicy
+}
+
+// lockWalk acquires the partition's walk slot, giving up if ctx is done first.
+func (state *partitionState) lockWalk(ctx context.Context) error {
+	select {
+	case state.walk <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (state *partitionState) unlockWalk() {
+	<-state.walk
+}
+
+// state returns the bookkeeping for a partition, creating it on first use.
+// Partitions can come into existence lazily through Schedule, so this is not
+// tied to RegisterPartition.
+func (engine *Engine) state(partitionID string) *partitionState {
+	engine.mu.RLock()
+	state, ok := engine.partitions[partitionID]
+	engine.mu.RUnlock()
+	if ok {
+		return state
+	}
+
+	engine.mu.Lock()
+	defer engine.mu.Unlock()
+
+	// double-check, another goroutine may have created it in the meantime
+	return engine.stateLocked(partitionID)
+}
+
+// stateLocked is state for a caller that holds engine.mu. A partition's
+// settings start out with the engine's default RetryPolicy.
+func (engine *Engine) stateLocked(partitionID string) *partitionState {
+	if state, ok := engine.partitions[partitionID]; ok {
+		return state
+	}
+	state := &partitionState{walk: make(chan struct{}, 1), retryPolicy: engine.defaultRetryPolicy}
+	engine.partitions[partitionID] = state
+	return state
+}
+
+// getLastAdvance returns a copy of the stats of the partition's latest walk,
+// or nil if it has never been walked.
+func (state *partitionState) getLastAdvance() *AdvanceStats {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if state.lastAdvance == nil {
+		return nil
+	}
+	stats := *state.lastAdvance
+	return &stats
+}
+
+func (state *partitionState) setLastAdvance(stats AdvanceStats) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	state.lastAdvance = &stats
+}
@@ -0,0 +1,90 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+)
+
+// PastPolicy decides what Schedule does with an event whose time is earlier
+// than its partition's clock. It applies to events scheduled by callers as
+// well as to causal events returned from Execute, so an event that tries to
+// bend time is caught at the point it is produced.
+type PastPolicy int
+
+const (
+	// PastAllow queues the event unchanged. It executes at the start of the
+	// next walk, at the partition's current time, since virtual time never
+	// moves backwards. This is the default.
+	PastAllow PastPolicy = iota
+
+	// PastReject refuses the event; Schedule returns an error wrapping
+	// ErrEventInPast, and a walk producing such an event fails.
+	PastReject
+
+	// PastClamp moves the event to the partition's current time, so it runs
+	// next without the clock ever going backwards.
+	PastClamp
+
+	// PastExecute runs the event synchronously inside Schedule against the
+	// partition's clock, waiting for any walk in progress to finish first,
+	// and schedules its causal events as usual. A causal event dated in the
+	// past is queued at the current time instead, so it runs next in the
+	// walk that produced it, under the walk's LoopLimits.
+	PastExecute
+)
+
+func (p PastPolicy) String() string {
+	switch p {
+	case PastAllow:
+		return "allow"
+	case PastReject:
+		return "reject"
+	case PastClamp:
+		return "clamp"
+	case PastExecute:
+		return "execute"
+	default:
+		return fmt.Sprintf("PastPolicy(%d)", int(p))
+	}
+}
+
+// MarshalText encodes the policy by name, so snapshot files stay readable.
+func (p PastPolicy) MarshalText() ([]byte, error) {
+	if p < PastAllow || p > PastExecute {
+		return nil, fmt.Errorf("invalid past policy %d", int(p))
+	}
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText decodes a policy written by MarshalText.
+func (p *PastPolicy) UnmarshalText(text []byte) error {
+	for candidate := PastAllow; candidate <= PastExecute; candidate++ {
+		if candidate.String() == string(text) {
+			*p = candidate
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown past policy %q", text)
+}
+
+// ErrEventInPast is wrapped by the error Schedule returns under PastReject.
+var ErrEventInPast = errors.New("event scheduled in the partition's past")
+
+// SetPastPolicy configures how a partition treats events scheduled before
+// its current time. The SYSTEM partition follows the wall-clock, where being
+// due already is the normal case, so it cannot be configured.
+func (engine *Engine) SetPastPolicy(partitionID string, policy PastPolicy) error {
+	if partitionID == "SYSTEM" {
+		return fmt.Errorf("invalid operation: the SYSTEM partition does not support a past policy")
+	}
+	if policy < PastAllow || policy > PastExecute {
+		return fmt.Errorf("invalid past policy %d", int(policy))
+	}
+
+	state := engine.state(partitionID)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	state.pastPolicy = policy
+	return nil
+}
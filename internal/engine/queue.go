@@ -1,69 +1,327 @@
 package engine
 
 import (
-	"container/heap"
+	"fmt"
+	"io"
 	"sync"
+	"time"
 )
 
+// EventQueue is a thread-safe priority queue of events ordered by execution
+// time. Events scheduled for the same instant execute in a deterministic
+// order: higher Priority first, then in the order they were pushed.
+//
+// The events themselves live in a QueueStore, in memory by default. Methods
+// that cannot return an error, such as Peek, report a store failure by
+// behaving as if the queue were empty; the failure is kept and returned by Err
+// until the queue's contents are replaced by clear or load. A push the store
+// rejects is only returned to its caller: it leaves the events already queued
+// intact.
 type EventQueue struct {
-	events []Event    // simply a slice which will be turned into a heap
-	mu     sync.Mutex // not read heavy in comparison to writes, so using Mutex instead of RWMutex
+	store QueueStore
+	seq   uint64     // next insertion sequence number
+	err   error      // first store failure, see Err
+	mu    sync.Mutex // not read heavy in comparison to writes, so using Mutex instead of RWMutex
 }
 
-func (q *EventQueue) Len() int {
-	return len(q.events)
+func NewEventQueue() *EventQueue {
+	return NewEventQueueWithStore(NewHeapStore())
 }
 
-func (q *EventQueue) Less(i, j int) bool {
-	return q.events[i].Time().Before(q.events[j].Time())
+// NewEventQueueWithStore returns a queue backed by the given store, which
+// must be empty.
+func NewEventQueueWithStore(store QueueStore) *EventQueue {
+	return &EventQueue{store: store}
 }
 
-func (q *EventQueue) Swap(i, j int) {
-	q.events[i], q.events[j] = q.events[j], q.events[i]
+// Err returns the first error reported by the queue's store since its contents
+// were last replaced, if any.
+func (q *EventQueue) Err() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.err
 }
 
-func (q *EventQueue) Push(x interface{}) {
-	event := x.(Event) // converting this back to event. Heap expects and interface{} type i.e. any datatype. But, we need to tell the compiler that we know that the x is Event.
-	q.events = append(q.events, event)
+// fail records a store error. The caller must hold q.mu.
+func (q *EventQueue) fail(err error) {
+	if err != nil && q.err == nil {
+		q.err = err
+	}
 }
 
-func (q *EventQueue) Pop() interface{} {
-	n := len(q.events)
-	event := q.events[n-1]
-	q.events = q.events[:n-1]
-	return event
+// Len returns the number of pending events in the queue.
+func (q *EventQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.store.Len()
 }
 
-// helpers to be made
+// PushEvent adds an event to the queue and returns the handle assigned to it.
+func (q *EventQueue) PushEvent(e Event) (EventID, error) {
+	id := newEventID()
+	if err := q.push(id, e); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
 
-func NewEventQueue() *EventQueue {
-	q := &EventQueue{
-		events: []Event{},
+// push inserts an event under an already assigned handle.
+func (q *EventQueue) push(id EventID, e Event) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.pushLocked(id, e)
+}
+
+func (q *EventQueue) pushLocked(id EventID, e Event) error {
+	q.seq++
+	err := q.store.Push(QueuedEvent{
+		ID:       id,
+		Event:    e,
+		Priority: priorityOf(e),
+		Seq:      q.seq,
+	})
+	if err != nil {
+		return fmt.Errorf("queue event %s: %w", e.Name(), err)
 	}
-	heap.Init(q)
-	return q
+	return nil
 }
 
-func (q *EventQueue) PushEvent(e Event) {
+// restore puts a popped item back with its original insertion sequence, so
+// it keeps its place among events sharing its timestamp.
+func (q *EventQueue) restore(item QueuedEvent) error {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 
-	heap.Push(q, e)
+	return q.store.Push(item)
 }
 
 func (q *EventQueue) PopEvent() Event {
+	_, e := q.popItem()
+	return e
+}
+
+// popItem removes the earliest event and returns it together with its handle.
+// It returns a nil event if the queue is empty or the store failed.
+func (q *EventQueue) popItem() (EventID, Event) {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 
-	return heap.Pop(q).(Event)
+	item, ok, err := q.store.Pop()
+	if err != nil || !ok {
+		q.fail(err)
+		return 0, nil
+	}
+	return item.ID, item.Event
+}
+
+// popDue removes the earliest event if it is due at or before at; a zero at
+// treats every event as due. Checking and removing happen in one step, so an
+// event cancelled or rescheduled after a Peek is never mistaken for the one
+// that was peeked. It returns false if nothing is due or the store failed.
+func (q *EventQueue) popDue(at time.Time) (QueuedEvent, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	head, ok, err := q.store.Peek()
+	if err != nil || !ok {
+		q.fail(err)
+		return QueuedEvent{}, false
+	}
+	if !at.IsZero() && head.Event.Time().After(at) {
+		return QueuedEvent{}, false
+	}
+	item, ok, err := q.store.Pop()
+	if err != nil || !ok {
+		q.fail(err)
+		return QueuedEvent{}, false
+	}
+	return item, true
 }
 
 func (q *EventQueue) Peek() Event {
+	next, _ := q.head()
+	return next
+}
+
+// clear drops every pending event.
+func (q *EventQueue) clear() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.clearLocked()
+}
+
+// clearLocked empties the store. An earlier store failure was about events
+// that are now gone, so it is forgotten; a failure to clear is kept instead.
+func (q *EventQueue) clearLocked() error {
+	q.err = nil
+	if clearer, ok := q.store.(interface{ Clear() error }); ok {
+		err := clearer.Clear()
+		q.fail(err)
+		return err
+	}
+	for q.store.Len() > 0 {
+		if _, _, err := q.store.Pop(); err != nil {
+			q.fail(err)
+			return err
+		}
+	}
+	return nil
+}
+
+// close releases the store's resources once its partition is gone.
+func (q *EventQueue) close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if closer, ok := q.store.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// load replaces the queue's contents with items, which must be in execution
+// order. Handles are kept; sequence numbers are re-issued in the same order.
+func (q *EventQueue) load(items []QueuedEvent) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := q.clearLocked(); err != nil {
+		return err
+	}
+	for _, item := range items {
+		if err := q.pushLocked(item.ID, item.Event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// head returns the next event and the number of pending events as one
+// consistent reading.
+func (q *EventQueue) head() (Event, int) {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 
-	if len(q.events) == 0 {
-		return nil
+	item, ok, err := q.store.Peek()
+	if err != nil || !ok {
+		q.fail(err)
+		return nil, 0
+	}
+	return item.Event, q.store.Len()
+}
+
+// ordered returns the queue's items in execution order without disturbing
+// the queue.
+func (q *EventQueue) ordered() []QueuedEvent {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	items := make([]QueuedEvent, 0, q.store.Len())
+	q.fail(q.store.Iterate(func(item QueuedEvent) bool {
+		items = append(items, item)
+		return true
+	}))
+	return items
+}
+
+// snapshot returns the pending events in execution order.
+func (q *EventQueue) snapshot() []Event {
+	items := q.ordered()
+	events := make([]Event, len(items))
+	for i, item := range items {
+		events[i] = item.Event
+	}
+	return events
+}
+
+// pending returns an immutable view of the queue in execution order.
+func (q *EventQueue) pending() []PendingEvent {
+	items := q.ordered()
+	events := make([]PendingEvent, len(items))
+	for i, item := range items {
+		events[i] = PendingEvent{
+			ID:       item.ID,
+			Name:     item.Event.Name(),
+			Time:     item.Event.Time(),
+			Priority: item.Priority,
+		}
+	}
+	return events
+}
+
+// Contains reports whether the event with the given handle is still pending.
+func (q *EventQueue) Contains(id EventID) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if finder, ok := q.store.(interface{ Contains(EventID) bool }); ok {
+		return finder.Contains(id)
+	}
+	found := false
+	q.fail(q.store.Iterate(func(item QueuedEvent) bool {
+		found = item.ID == id
+		return !found
+	}))
+	return found
+}
+
+// Remove deletes a pending event by handle. It returns false if the event
+// already executed or was never in this queue.
+func (q *EventQueue) Remove(id EventID) (Event, bool) {
+	item, ok := q.take(id)
+	return item.Event, ok
+}
+
+// take is Remove, returning the event together with its ordering keys so that
+// it can be put back in its place with restore.
+func (q *EventQueue) take(id EventID) (QueuedEvent, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	item, ok, err := q.store.Remove(id)
+	if err != nil || !ok {
+		q.fail(err)
+		return QueuedEvent{}, false
+	}
+	return item, true
+}
+
+// Reschedule moves a pending event to a new execution time. The event keeps
+// its handle and priority, but is treated as freshly inserted when breaking
+// ties with other events at the new time.
+func (q *EventQueue) Reschedule(id EventID, at time.Time) bool {
+	_, ok, _ := q.reschedule(id, at)
+	return ok
+}
+
+// reschedule is Reschedule, also returning the event as it was before the
+// move, so that the move can be undone with take and restore. If the store
+// rejects the moved event, the original is put back and the error returned.
+func (q *EventQueue) reschedule(id EventID, at time.Time) (QueuedEvent, bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	item, ok, err := q.store.Remove(id)
+	if err != nil || !ok {
+		q.fail(err)
+		return QueuedEvent{}, false, nil
+	}
+	if err := q.pushLocked(id, withTime(item.Event, at)); err != nil {
+		q.fail(q.store.Push(item))
+		return QueuedEvent{}, false, err
+	}
+	return item, true, nil
+}
+
+// priorityOf returns the explicit tie-break rank of an event, or 0 if the
+// event does not implement Prioritized.
+func priorityOf(e Event) int {
+	if p, ok := e.(Prioritized); ok {
+		return p.Priority()
 	}
-	return q.events[0]
+	return 0
 }
@@ -0,0 +1,266 @@
+package engine
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Verifier is an optional extension of Diagnostic. A walk calls Verify after
+// every executed event and stops with the returned error, so a diagnostic can
+// fail an Advance as soon as it sees something wrong.
+type Verifier interface {
+	Verify(partitionID string) error
+}
+
+// RecordedChild is a causal event produced by a RecordedStep.
+type RecordedChild struct {
+	Name string    `json:"name"`
+	Time time.Time `json:"time"`
+}
+
+// RecordedStep is one executed event and the causal events it produced.
+type RecordedStep struct {
+	Partition string          `json:"partition"`
+	Name      string          `json:"name"`
+	Time      time.Time       `json:"time"`
+	Children  []RecordedChild `json:"children,omitempty"`
+}
+
+// Recording is the execution history captured by a Recorder. It is plain
+// data, so it can be stored as JSON and replayed by a later run.
+type Recording struct {
+	Steps []RecordedStep `json:"steps"`
+}
+
+// Recorder is a Diagnostic that captures every event executed in a simulation
+// partition, the time it ran at and the children it produced. The SYSTEM
+// partition follows the wall clock and is not recorded.
+type Recorder struct {
+	mu      sync.Mutex
+	steps   []RecordedStep
+	current map[string]int // partition -> index of its latest step
+}
+
+// NewRecorder returns an empty Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{current: make(map[string]int)}
+}
+
+func (r *Recorder) OnAdvanceStart(id string, start, target time.Time) {}
+func (r *Recorder) OnAdvanceFinish(id string, current time.Time)      {}
+
+func (r *Recorder) OnEventExecute(id string, eventName string, t time.Time) {
+	if id == "SYSTEM" {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.current[id] = len(r.steps)
+	r.steps = append(r.steps, RecordedStep{Partition: id, Name: eventName, Time: t})
+}
+
+func (r *Recorder) OnEventCreated(id string, eventName string, eventTime time.Time, currentTime time.Time) {
+	if id == "SYSTEM" {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	index, ok := r.current[id]
+	if !ok {
+		return
+	}
+	step := &r.steps[index]
+	step.Children = append(step.Children, RecordedChild{Name: eventName, Time: eventTime})
+}
+
+// Recording returns a copy of everything recorded so far.
+func (r *Recorder) Recording() Recording {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	steps := make([]RecordedStep, len(r.steps))
+	for i, step := range r.steps {
+		step.Children = append([]RecordedChild(nil), step.Children...)
+		steps[i] = step
+	}
+	return Recording{Steps: steps}
+}
+
+// DivergenceError describes the first point at which a replay stopped
+// matching its recording. Step is the index of the event within the
+// partition's own history; Want or Got is empty if one side ran out of events.
+type DivergenceError struct {
+	Partition string
+	Step      int
+	Want      string
+	Got       string
+}
+
+func (e *DivergenceError) Error() string {
+	return fmt.Sprintf("replay of partition %s diverged at event #%d: want %s, got %s",
+		e.Partition, e.Step, orNothing(e.Want), orNothing(e.Got))
+}
+
+func orNothing(s string) string {
+	if s == "" {
+		return "nothing"
+	}
+	return s
+}
+
+// Replayer is a Diagnostic that checks a run against a Recording. Histories
+// are compared per partition, so partitions may be advanced in any order or
+// concurrently. Because it is a Verifier, a walk stops at the first
+// divergence in its partition and Advance returns a *DivergenceError; other
+// partitions keep replaying.
+type Replayer struct {
+	mu          sync.Mutex
+	want        map[string][]RecordedStep   // per partition, in execution order
+	next        map[string]int              // per partition, index of the next expected step
+	children    map[string]int              // per partition, children seen for the current step
+	divergences map[string]*DivergenceError // per partition, the first divergence
+	first       *DivergenceError            // the first divergence in any partition
+}
+
+// NewReplayer returns a Replayer expecting the given recording.
+func NewReplayer(recording Recording) *Replayer {
+	want := make(map[string][]RecordedStep)
+	for _, step := range recording.Steps {
+		want[step.Partition] = append(want[step.Partition], step)
+	}
+	return &Replayer{
+		want:        want,
+		next:        make(map[string]int),
+		children:    make(map[string]int),
+		divergences: make(map[string]*DivergenceError),
+	}
+}
+
+func (r *Replayer) OnAdvanceStart(id string, start, target time.Time) {}
+func (r *Replayer) OnAdvanceFinish(id string, current time.Time)      {}
+
+func (r *Replayer) OnEventExecute(id string, eventName string, t time.Time) {
+	if id == "SYSTEM" {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.divergences[id] != nil {
+		return
+	}
+	r.checkChildren(id)
+	if r.divergences[id] != nil {
+		return
+	}
+
+	index := r.next[id]
+	got := describeStep(eventName, t)
+	steps := r.want[id]
+	if index >= len(steps) {
+		r.diverge(id, index, "", got)
+		return
+	}
+	if step := steps[index]; step.Name != eventName || !step.Time.Equal(t) {
+		r.diverge(id, index, describeStep(step.Name, step.Time), got)
+		return
+	}
+	r.next[id] = index + 1
+	r.children[id] = 0
+}
+
+func (r *Replayer) OnEventCreated(id string, eventName string, eventTime time.Time, currentTime time.Time) {
+	if id == "SYSTEM" {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.divergences[id] != nil || r.next[id] == 0 {
+		return
+	}
+
+	index := r.next[id] - 1
+	step := r.want[id][index]
+	child := r.children[id]
+	got := "child " + describeStep(eventName, eventTime)
+	if child >= len(step.Children) {
+		r.diverge(id, index, "no more children", got)
+		return
+	}
+	if want := step.Children[child]; want.Name != eventName || !want.Time.Equal(eventTime) {
+		r.diverge(id, index, "child "+describeStep(want.Name, want.Time), got)
+		return
+	}
+	r.children[id] = child + 1
+}
+
+// Verify reports the first divergence in the partition, including a step
+// that produced fewer children than recorded.
+func (r *Replayer) Verify(partitionID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.checkChildren(partitionID)
+	if divergence := r.divergences[partitionID]; divergence != nil {
+		return divergence
+	}
+	return nil
+}
+
+// Finish reports the first divergence, or a partition whose recording has
+// events the replay never reached. Call it once the scenario has been re-run.
+func (r *Replayer) Finish() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ids := slices.Sorted(maps.Keys(r.want))
+	for _, id := range ids {
+		r.checkChildren(id)
+	}
+	if r.first != nil {
+		return r.first
+	}
+	for _, id := range ids {
+		if steps, index := r.want[id], r.next[id]; index < len(steps) {
+			r.diverge(id, index, describeStep(steps[index].Name, steps[index].Time), "")
+			return r.first
+		}
+	}
+	return nil
+}
+
+// checkChildren flags the partition's current step if it produced fewer
+// children than recorded. The caller must hold r.mu.
+func (r *Replayer) checkChildren(id string) {
+	if r.divergences[id] != nil || r.next[id] == 0 {
+		return
+	}
+	index := r.next[id] - 1
+	step := r.want[id][index]
+	if seen := r.children[id]; seen < len(step.Children) {
+		missing := step.Children[seen]
+		r.diverge(id, index, "child "+describeStep(missing.Name, missing.Time), "")
+	}
+}
+
+func (r *Replayer) diverge(id string, step int, want, got string) {
+	divergence := &DivergenceError{Partition: id, Step: step, Want: want, Got: got}
+	r.divergences[id] = divergence
+	if r.first == nil {
+		r.first = divergence
+	}
+}
+
+func describeStep(name string, t time.Time) string {
+	return fmt.Sprintf("%s at %s", name, t.Format(time.RFC3339Nano))
+}
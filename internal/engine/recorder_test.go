@@ -0,0 +1,130 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aaryansingh-dev/hybrid-logical-time-go/internal/clock"
+)
+
+func TestRecorder_ReplayOfSameScenarioMatches(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	target := start.AddDate(0, 3, 0)
+
+	recorder := NewRecorder()
+	eng, _ := newBillingChain(recorder, "recorded", start)
+	if err := eng.Advance(context.Background(), "recorded", target); err != nil {
+		t.Fatal(err)
+	}
+
+	// the recording has to survive a trip through JSON to be useful across runs
+	data, err := json.Marshal(recorder.Recording())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var recording Recording
+	if err := json.Unmarshal(data, &recording); err != nil {
+		t.Fatal(err)
+	}
+	if len(recording.Steps) != 5 || len(recording.Steps[0].Children) != 1 {
+		t.Fatalf("Unexpected recording: %+v", recording.Steps)
+	}
+
+	replayer := NewReplayer(recording)
+	eng, _ = newBillingChain(replayer, "recorded", start)
+	if err := eng.Advance(context.Background(), "recorded", target); err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if err := replayer.Finish(); err != nil {
+		t.Errorf("Expected no divergence, got %v", err)
+	}
+}
+
+func TestReplayer_StopsAtFirstDivergence(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	id := "flaky"
+
+	// the retry delay depends on how often the event has run, not on virtual time
+	runs := 0
+	scenario := func(diag Diagnostic) *Engine {
+		eng := NewEngine(diag)
+		eng.RegisterPartition(id, clock.NewTestClock(start))
+		eng.Schedule(&MockEvent{executionTime: start, name: "Payment", clockID: id, onExecute: func(tp clock.TimeProvider) []Event {
+			runs++
+			return []Event{&MockEvent{executionTime: tp.Now().Add(time.Duration(runs) * time.Hour), name: "Retry", clockID: id}}
+		}})
+		eng.Schedule(&MockEvent{executionTime: start.Add(10 * time.Hour), name: "Later", clockID: id})
+		return eng
+	}
+
+	recorder := NewRecorder()
+	scenario(recorder).Advance(context.Background(), id, start.Add(24*time.Hour))
+
+	replayer := NewReplayer(recorder.Recording())
+	eng := scenario(replayer)
+	err := eng.Advance(context.Background(), id, start.Add(24*time.Hour))
+
+	var divergence *DivergenceError
+	if !errors.As(err, &divergence) {
+		t.Fatalf("Expected a DivergenceError, got %v", err)
+	}
+	if divergence.Step != 0 || divergence.Got == "" || divergence.Want == "" {
+		t.Errorf("Expected the divergence at the first step's child, got %+v", divergence)
+	}
+	if pending, _ := eng.ListPendingEvents(id); len(pending) != 2 {
+		t.Errorf("Expected the walk to stop right after the diverging event, %d events left", len(pending))
+	}
+}
+
+func TestReplayer_DetectsShortReplay(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	recorder := NewRecorder()
+	eng, _ := newBillingChain(recorder, "short", start)
+	eng.Advance(context.Background(), "short", start.AddDate(0, 2, 0))
+
+	replayer := NewReplayer(recorder.Recording())
+	eng, _ = newBillingChain(replayer, "short", start)
+	eng.Advance(context.Background(), "short", start.AddDate(0, 1, 0))
+
+	var divergence *DivergenceError
+	if err := replayer.Finish(); !errors.As(err, &divergence) || divergence.Got != "" {
+		t.Errorf("Expected a divergence for the missing events, got %v", err)
+	}
+}
+
+func TestReplayer_DivergenceStaysInItsPartition(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	delay := time.Hour
+	scenario := func(diag Diagnostic) *Engine {
+		eng := NewEngine(diag)
+		eng.RegisterPartition("diverging", clock.NewTestClock(start))
+		eng.Schedule(&MockEvent{executionTime: start.Add(delay), name: "Payment", clockID: "diverging"})
+		eng.RegisterPartition("steady", clock.NewTestClock(start))
+		scheduleChain(eng, "steady", start)
+		return eng
+	}
+
+	recorder := NewRecorder()
+	recorded := scenario(recorder)
+	recorded.Advance(context.Background(), "diverging", start.Add(24*time.Hour))
+	recorded.Advance(context.Background(), "steady", start.Add(24*time.Hour))
+
+	delay = 2 * time.Hour
+	replayer := NewReplayer(recorder.Recording())
+	eng := scenario(replayer)
+	var divergence *DivergenceError
+	if err := eng.Advance(context.Background(), "diverging", start.Add(24*time.Hour)); !errors.As(err, &divergence) {
+		t.Fatalf("Expected a DivergenceError, got %v", err)
+	}
+	if err := eng.Advance(context.Background(), "steady", start.Add(24*time.Hour)); err != nil {
+		t.Errorf("Expected the other partition to replay cleanly, got %v", err)
+	}
+	if err := replayer.Finish(); !errors.As(err, &divergence) || divergence.Partition != "diverging" {
+		t.Errorf("Expected Finish to report the diverging partition, got %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// ErrUnknownEventType is returned when decoding an event whose type name has
+// not been registered, or encoding an event whose Go type has not.
+var ErrUnknownEventType = errors.New("unknown event type")
+
+// Registry maps stable type names to event factories. Names are what get
+// written to disk or sent over the wire, so they must not change once data
+// has been persisted, even if the Go type is renamed or moved.
+type Registry struct {
+	mu     sync.RWMutex
+	byName map[string]func() Event
+	byType map[reflect.Type]string
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		byName: make(map[string]func() Event),
+		byType: make(map[reflect.Type]string),
+	}
+}
+
+// DefaultRegistry is used by every Engine unless SetRegistry is called.
+// Packages defining events register them here from init, as billing does.
+var DefaultRegistry = NewRegistry()
+
+// RegisterEventType registers an event type with DefaultRegistry and panics
+// on conflicts. It is meant to be called from init functions.
+func RegisterEventType(name string, factory func() Event) {
+	if err := DefaultRegistry.Register(name, factory); err != nil {
+		panic("engine: " + err.Error())
+	}
+}
+
+// Register makes an event type encodable under a stable name. The factory
+// must return a fresh, empty instance that encoding/json can decode into;
+// events with unexported fields implement json.Marshaler and
+// json.Unmarshaler themselves. A name or Go type can only be registered once.
+func (r *Registry) Register(name string, factory func() Event) error {
+	if name == "" {
+		return fmt.Errorf("register event type: empty name")
+	}
+	if factory == nil {
+		return fmt.Errorf("register event type %q: nil factory", name)
+	}
+	sample := factory()
+	if sample == nil {
+		return fmt.Errorf("register event type %q: factory returned nil", name)
+	}
+	goType := reflect.TypeOf(sample)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.byName[name]; exists {
+		return fmt.Errorf("event type %q registered twice", name)
+	}
+	if existing, exists := r.byType[goType]; exists {
+		return fmt.Errorf("event type %q: %s is already registered as %q", name, goType, existing)
+	}
+	r.byName[name] = factory
+	r.byType[goType] = name
+	return nil
+}
+
+// New returns a fresh, empty instance of the named event type.
+func (r *Registry) New(name string) (Event, error) {
+	r.mu.RLock()
+	factory, ok := r.byName[name]
+	r.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownEventType, name)
+	}
+	return factory(), nil
+}
+
+// NameOf returns the registered name of an event's type. Events moved with
+// Reschedule report the name of the original event.
+func (r *Registry) NameOf(event Event) (string, error) {
+	event = unwrapEvent(event)
+
+	r.mu.RLock()
+	name, ok := r.byType[reflect.TypeOf(event)]
+	r.mu.RUnlock()
+
+	if !ok {
+		return "", fmt.Errorf("%w %T", ErrUnknownEventType, event)
+	}
+	return name, nil
+}
+
+// Names lists the registered type names in sorted order.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.byName))
+	for name := range r.byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetRegistry replaces the registry the engine uses to encode and decode
+// events, for example to keep test-only event types out of DefaultRegistry.
+func (engine *Engine) SetRegistry(registry *Registry) {
+	engine.mu.Lock()
+	defer engine.mu.Unlock()
+
+	engine.codec = NewCodec(registry)
+}
+
+func (engine *Engine) getCodec() *Codec {
+	engine.mu.RLock()
+	defer engine.mu.RUnlock()
+
+	return engine.codec
+}
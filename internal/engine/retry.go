@@ -0,0 +1,364 @@
+package engine
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"time"
+
+	"github.com/aaryansingh-dev/hybrid-logical-time-go/internal/clock"
+)
+
+// Fallible is an optional interface for events whose side effect can fail.
+// The engine calls TryExecute instead of Execute. When it returns an error,
+// the events it returned are discarded and the partition's RetryPolicy
+// decides what happens next: the event is queued again under the same handle
+// after a backoff on the partition's clock, or, once its retries are used
+// up, moved to the partition's dead-letter queue. Either way the walk carries
+// on; a failed side effect is not a failed Advance.
+type Fallible interface {
+	TryExecute(timeProvider clock.TimeProvider) ([]Event, error)
+}
+
+// RetryCounter is implemented by events that kept their own retry count
+// before the engine did, such as billing.PaymentAttempt. Restore takes the
+// count from it when it reads a version 1 snapshot, so a retry in flight
+// keeps the retries it has used up.
+type RetryCounter interface {
+	RetryCount() int
+}
+
+// Backoff selects how the delay between retries grows.
+type Backoff int
+
+const (
+	// BackoffFixed waits Delay before every retry.
+	BackoffFixed Backoff = iota
+
+	// BackoffLinear waits Delay before the first retry, 2*Delay before the
+	// second, and so on.
+	BackoffLinear
+
+	// BackoffExponential doubles the wait before every retry, starting at Delay.
+	BackoffExponential
+)
+
+func (b Backoff) String() string {
+	switch b {
+	case BackoffFixed:
+		return "fixed"
+	case BackoffLinear:
+		return "linear"
+	case BackoffExponential:
+		return "exponential"
+	default:
+		return fmt.Sprintf("Backoff(%d)", int(b))
+	}
+}
+
+// MarshalText encodes the backoff by name, so snapshot files stay readable.
+func (b Backoff) MarshalText() ([]byte, error) {
+	if b < BackoffFixed || b > BackoffExponential {
+		return nil, fmt.Errorf("invalid backoff %d", int(b))
+	}
+	return []byte(b.String()), nil
+}
+
+// UnmarshalText decodes a backoff written by MarshalText.
+func (b *Backoff) UnmarshalText(text []byte) error {
+	for candidate := BackoffFixed; candidate <= BackoffExponential; candidate++ {
+		if candidate.String() == string(text) {
+			*b = candidate
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown backoff %q", text)
+}
+
+// RetryPolicy decides how a partition retries Fallible events. The zero
+// value never retries: an event goes to the dead-letter queue on its first
+// failure.
+type RetryPolicy struct {
+	MaxRetries int           `json:"max_retries"` // retries after the first attempt
+	Backoff    Backoff       `json:"backoff"`     // how the delay grows
+	Delay      time.Duration `json:"delay"`       // the fixed delay, linear step or exponential base
+	MaxDelay   time.Duration `json:"max_delay"`   // caps the delay before jitter; zero means uncapped
+
+	// Jitter spreads every delay by up to plus or minus this fraction of
+	// itself, between 0 and 1. The spread is derived from the partition,
+	// event and retry number rather than from a random source, so a
+	// simulation retries at the same virtual times on every run.
+	Jitter float64 `json:"jitter"`
+}
+
+// FixedRetry retries up to maxRetries times, delay apart.
+func FixedRetry(maxRetries int, delay time.Duration) RetryPolicy {
+	return RetryPolicy{MaxRetries: maxRetries, Backoff: BackoffFixed, Delay: delay}
+}
+
+// LinearRetry retries up to maxRetries times, waiting step, 2*step, 3*step...
+func LinearRetry(maxRetries int, step time.Duration) RetryPolicy {
+	return RetryPolicy{MaxRetries: maxRetries, Backoff: BackoffLinear, Delay: step}
+}
+
+// ExponentialRetry retries up to maxRetries times, waiting base, 2*base,
+// 4*base... but never more than maxDelay, unless maxDelay is zero.
+func ExponentialRetry(maxRetries int, base, maxDelay time.Duration) RetryPolicy {
+	return RetryPolicy{MaxRetries: maxRetries, Backoff: BackoffExponential, Delay: base, MaxDelay: maxDelay}
+}
+
+// WithJitter returns the policy with its delays spread by the given fraction.
+func (p RetryPolicy) WithJitter(fraction float64) RetryPolicy {
+	p.Jitter = fraction
+	return p
+}
+
+func (p RetryPolicy) validate() error {
+	switch {
+	case p.MaxRetries < 0:
+		return fmt.Errorf("invalid retry policy: negative MaxRetries %d", p.MaxRetries)
+	case p.Backoff < BackoffFixed || p.Backoff > BackoffExponential:
+		return fmt.Errorf("invalid retry policy: unknown backoff %d", int(p.Backoff))
+	case p.Delay < 0 || p.MaxDelay < 0:
+		return fmt.Errorf("invalid retry policy: negative delay")
+	case p.Jitter < 0 || p.Jitter > 1:
+		return fmt.Errorf("invalid retry policy: jitter %v is outside [0, 1]", p.Jitter)
+	}
+	return nil
+}
+
+// delay returns how long to wait before the given retry, counting from 1.
+// seed makes the jitter of one event differ from that of another.
+func (p RetryPolicy) delay(retry int, seed string) time.Duration {
+	d := p.Delay
+	switch p.Backoff {
+	case BackoffLinear:
+		d = p.Delay * time.Duration(retry)
+	case BackoffExponential:
+		for i := 1; i < retry && d <= math.MaxInt64/2; i++ {
+			d *= 2
+			if p.MaxDelay > 0 && d >= p.MaxDelay {
+				break
+			}
+		}
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+
+	if p.Jitter > 0 {
+		hash := fnv.New64a()
+		fmt.Fprintf(hash, "%s/%d", seed, retry)
+		spread := float64(hash.Sum64())/float64(1<<64)*2 - 1 // in [-1, 1)
+		d += time.Duration(float64(d) * p.Jitter * spread)
+	}
+	return d
+}
+
+// SetRetryPolicy configures how a partition retries Fallible events. Unlike
+// the PastPolicy it also applies to SYSTEM, where retries follow the
+// wall-clock.
+func (engine *Engine) SetRetryPolicy(partitionID string, policy RetryPolicy) error {
+	if err := policy.validate(); err != nil {
+		return err
+	}
+
+	state := engine.state(partitionID)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	state.retryPolicy = policy
+	return nil
+}
+
+// SetDefaultRetryPolicy sets the RetryPolicy of every partition that comes
+// into existence from now on, however it does: registered, created by
+// Schedule, or restored from a snapshot that predates retry policies. Forks
+// still copy their source's policy, and partitions that already exist keep
+// theirs; SYSTEM exists from the start, so its policy is set with
+// SetRetryPolicy.
+func (engine *Engine) SetDefaultRetryPolicy(policy RetryPolicy) error {
+	if err := policy.validate(); err != nil {
+		return err
+	}
+
+	engine.mu.Lock()
+	defer engine.mu.Unlock()
+
+	engine.defaultRetryPolicy = policy
+	return nil
+}
+
+// DeadLetter is a Fallible event that failed on every attempt its partition's
+// RetryPolicy allowed.
+type DeadLetter struct {
+	ID       EventID
+	Name     string
+	Time     time.Time // the partition's virtual time at the last attempt
+	Attempts int
+	Err      error // the error of the last attempt
+
+	event Event
+}
+
+// DeadLetters returns a partition's dead-letter queue, oldest first. The
+// queue is saved with checkpoints and snapshots, but it is not part of the
+// SYSTEM journal.
+func (engine *Engine) DeadLetters(partitionID string) ([]DeadLetter, error) {
+	if _, ok := engine.queueFor(partitionID); !ok {
+		return nil, fmt.Errorf("partition %s not found", partitionID)
+	}
+
+	state := engine.state(partitionID)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	return append([]DeadLetter(nil), state.deadLetters...), nil
+}
+
+// Redrive takes an event out of a partition's dead-letter queue and schedules
+// it again at the partition's current time, under its old handle and with a
+// fresh set of retries.
+func (engine *Engine) Redrive(partitionID string, id EventID) error {
+	if _, ok := engine.queueFor(partitionID); !ok {
+		return fmt.Errorf("partition %s not found", partitionID)
+	}
+
+	state := engine.state(partitionID)
+	letter, ok := state.takeDeadLetter(id)
+	if !ok {
+		return fmt.Errorf("redrive event %d: %w", id, ErrEventNotFound)
+	}
+
+	now := time.Now()
+	if partitionID != "SYSTEM" {
+		if current, err := engine.GetPartitionTime(partitionID); err == nil {
+			now = current
+		}
+	}
+	event := withTime(letter.event, now)
+
+	var err error
+	if partitionID == "SYSTEM" {
+		err = engine.journalSchedule(id, event)
+	}
+	if err == nil {
+		err = engine.schedule(id, 0, event)
+	}
+	if err != nil {
+		state.mu.Lock()
+		state.deadLetters = append([]DeadLetter{letter}, state.deadLetters...)
+		state.mu.Unlock()
+		return fmt.Errorf("redrive event %d: %w", id, err)
+	}
+	return nil
+}
+
+// retryOrDeadLetter applies the partition's RetryPolicy to a Fallible event
+// that just failed. It is called from execute, in place of scheduling the
+// event's children, and by the real-time worker for a SYSTEM event that
+// panicked.
+func (engine *Engine) retryOrDeadLetter(
+	partitionID string, id EventID, event Event, provider clock.TimeProvider, cause error,
+) error {
+	state := engine.state(partitionID)
+	policy := state.getRetryPolicy()
+
+	state.mu.Lock()
+	if state.attempts == nil {
+		state.attempts = make(map[EventID]int)
+	}
+	state.attempts[id]++
+	attempts := state.attempts[id]
+	state.mu.Unlock()
+
+	queue, ok := engine.queueFor(partitionID)
+	if !ok {
+		return fmt.Errorf("partition %s not found", partitionID)
+	}
+
+	if attempts <= policy.MaxRetries {
+		seed := fmt.Sprintf("%s/%s/%s", partitionID, event.Name(), unwrapEvent(event).Time().Format(time.RFC3339Nano))
+		retry := withTime(event, provider.Now().Add(policy.delay(attempts, seed)))
+
+		// the retry keeps the handle, so the journal sees it as the
+		// execution's only child
+		if err := engine.journalExecute(partitionID, id, []EventID{id}, []Event{retry}); err != nil {
+			return err
+		}
+		if err := queue.push(id, retry); err != nil {
+			return err
+		}
+		engine.observe(EngineEvent{
+			Kind:        EngineEventRetrying,
+			PartitionID: partitionID,
+			EventID:     id,
+			EventName:   event.Name(),
+			EventTime:   retry.Time(),
+			VirtualTime: provider.Now(),
+			Err:         cause,
+		})
+		return nil
+	}
+
+	if err := engine.journalExecute(partitionID, id, nil, nil); err != nil {
+		return err
+	}
+	state.mu.Lock()
+	delete(state.attempts, id)
+	state.deadLetters = append(state.deadLetters, DeadLetter{
+		ID:       id,
+		Name:     event.Name(),
+		Time:     provider.Now(),
+		Attempts: attempts,
+		Err:      cause,
+		event:    unwrapEvent(event),
+	})
+	state.mu.Unlock()
+
+	engine.observe(EngineEvent{
+		Kind:        EngineEventDeadLettered,
+		PartitionID: partitionID,
+		EventID:     id,
+		EventName:   event.Name(),
+		EventTime:   event.Time(),
+		VirtualTime: provider.Now(),
+		Err:         cause,
+	})
+	return nil
+}
+
+// takeDeadLetter removes an event from the dead-letter queue.
+func (state *partitionState) takeDeadLetter(id EventID) (DeadLetter, bool) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	for i, letter := range state.deadLetters {
+		if letter.ID == id {
+			state.deadLetters = append(state.deadLetters[:i:i], state.deadLetters[i+1:]...)
+			return letter, true
+		}
+	}
+	return DeadLetter{}, false
+}
+
+// forgetAttempts drops the retry count of an event that succeeded.
+func (state *partitionState) forgetAttempts(id EventID) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	delete(state.attempts, id)
+}
+
+// queueFor returns the queue of a partition, including SYSTEM.
+func (engine *Engine) queueFor(partitionID string) (*EventQueue, bool) {
+	if partitionID == "SYSTEM" {
+		return engine.systemQueue, true
+	}
+
+	engine.mu.RLock()
+	defer engine.mu.RUnlock()
+
+	queue, ok := engine.queues[partitionID]
+	return queue, ok
+}
@@ -0,0 +1,325 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aaryansingh-dev/hybrid-logical-time-go/internal/clock"
+)
+
+var errDeclined = errors.New("card declined")
+
+// flakyEvent fails until failuresLeft runs out
+type flakyEvent struct {
+	MockEvent
+	failuresLeft *int
+}
+
+func (e *flakyEvent) TryExecute(tp clock.TimeProvider) ([]Event, error) {
+	if *e.failuresLeft > 0 {
+		*e.failuresLeft--
+		return []Event{&MockEvent{executionTime: tp.Now(), name: "Discarded", clockID: e.clockID}}, errDeclined
+	}
+	return nil, nil
+}
+
+func newFlaky(at time.Time, partition string, failures int) *flakyEvent {
+	return &flakyEvent{MockEvent: MockEvent{executionTime: at, name: "Charge", clockID: partition}, failuresLeft: &failures}
+}
+
+// decliningEvent always fails; unlike flakyEvent it can be checkpointed
+type decliningEvent struct {
+	persistentEvent
+}
+
+func (e *decliningEvent) TryExecute(tp clock.TimeProvider) ([]Event, error) {
+	return nil, errDeclined
+}
+
+// countedEvent is a decliningEvent that kept its own retry count, like events
+// written before the engine counted retries
+type countedEvent struct {
+	decliningEvent
+	Retry int
+}
+
+func (e *countedEvent) RetryCount() int { return e.Retry }
+
+func init() {
+	RegisterEventType("engine_test.decliningEvent", func() Event { return &decliningEvent{} })
+	RegisterEventType("engine_test.countedEvent", func() Event { return &countedEvent{} })
+}
+
+func TestRetryPolicy_RetriesOnThePartitionClock(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	recorder := NewTimelineRecorder()
+	eng := NewEngine(recorder)
+	eng.RegisterPartition("retry", clock.NewTestClock(start))
+	if err := eng.SetRetryPolicy("retry", LinearRetry(3, time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	id, _ := eng.Schedule(newFlaky(start, "retry", 2))
+	if err := eng.Advance(context.Background(), "retry", start.Add(24*time.Hour)); err != nil {
+		t.Fatalf("A failing side effect must not fail the walk: %v", err)
+	}
+
+	charges := recorder.Timeline("retry").Named("Charge")
+	if charges.Len() != 3 {
+		t.Fatalf("Expected 3 attempts, got\n%s", recorder.Timeline("retry"))
+	}
+	// linear backoff: 1h after the first failure, 2h after the second
+	if third, _ := charges.Nth("Charge", 3); !third.Time.Equal(start.Add(3 * time.Hour)) {
+		t.Errorf("Expected the third attempt at 03:00, got %s", third.Time)
+	}
+	if recorder.Timeline("retry").Occurred("Discarded") {
+		t.Error("Expected the events returned with an error to be discarded")
+	}
+	if letters, _ := eng.DeadLetters("retry"); len(letters) != 0 {
+		t.Errorf("Expected no dead letters, got %+v", letters)
+	}
+	if err := eng.Cancel(id); !errors.Is(err, ErrEventNotFound) {
+		t.Errorf("Expected the event to be done after succeeding, got %v", err)
+	}
+}
+
+func TestRetryPolicy_DeadLettersAndRedrives(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	log := &engineLog{}
+	eng := NewEngine(log)
+	eng.RegisterPartition("dlq", clock.NewTestClock(start))
+	eng.SetRetryPolicy("dlq", FixedRetry(1, 10*time.Minute))
+
+	flaky := newFlaky(start, "dlq", 2)
+	id, _ := eng.Schedule(flaky)
+	if err := eng.Advance(context.Background(), "dlq", start.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	letters, _ := eng.DeadLetters("dlq")
+	if len(letters) != 1 || letters[0].ID != id || letters[0].Attempts != 2 || !errors.Is(letters[0].Err, errDeclined) ||
+		!letters[0].Time.Equal(start.Add(10*time.Minute)) {
+		t.Fatalf("Expected the event dead-lettered after 2 attempts, got %+v", letters)
+	}
+	var retried, deadLettered int
+	for _, event := range log.take() {
+		switch event.Kind {
+		case EngineEventRetrying:
+			retried++
+		case EngineEventDeadLettered:
+			deadLettered++
+		}
+	}
+	if retried != 1 || deadLettered != 1 {
+		t.Errorf("Expected 1 retry and 1 dead letter to be observed, got %d and %d", retried, deadLettered)
+	}
+
+	// the side effect has been fixed, so the redriven event goes through
+	*flaky.failuresLeft = 0
+	if err := eng.Redrive("dlq", id); err != nil {
+		t.Fatal(err)
+	}
+	if pending, _ := eng.ListPendingEvents("dlq"); len(pending) != 1 || pending[0].ID != id || !pending[0].Time.Equal(start.Add(time.Hour)) {
+		t.Fatalf("Expected the event back in the queue at the partition's time, got %+v", pending)
+	}
+	if err := eng.Redrive("dlq", id); !errors.Is(err, ErrEventNotFound) {
+		t.Errorf("Expected a second redrive to fail, got %v", err)
+	}
+	if _, err := eng.Step(context.Background(), "dlq", 1); err != nil {
+		t.Fatal(err)
+	}
+	if letters, _ := eng.DeadLetters("dlq"); len(letters) != 0 {
+		t.Errorf("Expected an empty dead-letter queue, got %+v", letters)
+	}
+}
+
+func TestRetryPolicy_RewindRestoresDeadLetters(t *testing.T) {
+	forEachStore(t, func(t *testing.T, factory QueueFactory) {
+		start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		eng := NewEngine(nil)
+		eng.SetQueueFactory(factory)
+		eng.RegisterPartition("rewind", clock.NewTestClock(start))
+
+		id, _ := eng.Schedule(&decliningEvent{persistentEvent{At: start.Add(time.Hour), Label: "Charge", Partition: "rewind"}})
+		if err := eng.Checkpoint("rewind", "before"); err != nil {
+			t.Fatal(err)
+		}
+		eng.Advance(context.Background(), "rewind", start.Add(2*time.Hour))
+		if letters, _ := eng.DeadLetters("rewind"); len(letters) != 1 {
+			t.Fatalf("Expected the event dead-lettered, got %+v", letters)
+		}
+		if err := eng.Checkpoint("rewind", "after"); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := eng.Rewind("rewind", "before"); err != nil {
+			t.Fatal(err)
+		}
+		if letters, _ := eng.DeadLetters("rewind"); len(letters) != 0 {
+			t.Errorf("Expected the dead letter of the discarded timeline to be gone, got %+v", letters)
+		}
+		// the event is pending again, so it cannot also be redriven
+		if err := eng.Redrive("rewind", id); !errors.Is(err, ErrEventNotFound) {
+			t.Errorf("Expected no redrive of a pending event, got %v", err)
+		}
+		if pending, _ := eng.ListPendingEvents("rewind"); len(pending) != 1 || pending[0].ID != id {
+			t.Errorf("Expected the event pending once, got %+v", pending)
+		}
+
+		// rewinding forward again brings back a dead letter that can be redriven
+		if err := eng.Rewind("rewind", "after"); err != nil {
+			t.Fatal(err)
+		}
+		if err := eng.Redrive("rewind", id); err != nil {
+			t.Errorf("Expected the restored dead letter to be redriven, got %v", err)
+		}
+	})
+}
+
+func TestRetryPolicy_SnapshotKeepsRetryCountsAndDeadLetters(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	src := NewEngine(nil)
+	src.RegisterPartition("saved", clock.NewTestClock(start))
+	src.SetRetryPolicy("saved", FixedRetry(1, time.Hour))
+
+	src.Schedule(&decliningEvent{persistentEvent{At: start.Add(time.Hour), Label: "Lost", Partition: "saved"}})
+	src.Schedule(&decliningEvent{persistentEvent{At: start.Add(3 * time.Hour), Label: "Charge", Partition: "saved"}})
+	// Lost fails twice and is dead-lettered, Charge fails once and waits for its last retry
+	if err := src.Advance(context.Background(), "saved", start.Add(3*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := src.Snapshot("saved", &buf); err != nil {
+		t.Fatal(err)
+	}
+	dst := NewEngine(nil)
+	if _, err := dst.Restore(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	letters, _ := dst.DeadLetters("saved")
+	if len(letters) != 1 || letters[0].Name != "Lost" || letters[0].Attempts != 2 || letters[0].Err == nil ||
+		letters[0].Err.Error() != errDeclined.Error() || !letters[0].Time.Equal(start.Add(2*time.Hour)) {
+		t.Fatalf("Expected the dead letter to be restored, got %+v", letters)
+	}
+	lost := letters[0].ID
+
+	// the retry left to Charge is the last one, so its next failure dead-letters it
+	pending, _ := dst.ListPendingEvents("saved")
+	var charge EventID
+	for _, event := range pending {
+		if event.Name == "Charge" {
+			charge = event.ID
+		}
+	}
+	if _, err := dst.RunUntil(context.Background(), "saved", func(event Event) bool { return event.Name() == "Charge" }); err != nil {
+		t.Fatal(err)
+	}
+	letters, _ = dst.DeadLetters("saved")
+	if len(letters) != 2 || letters[1].ID != charge || letters[1].Attempts != 2 {
+		t.Errorf("Expected Charge dead-lettered after its second attempt, got %+v", letters)
+	}
+	if err := dst.Redrive("saved", lost); err != nil {
+		t.Errorf("Expected the restored dead letter to be redriven, got %v", err)
+	}
+}
+
+func TestRetryPolicy_RestoresVersion1RetryCounts(t *testing.T) {
+	eng := NewEngine(nil)
+	eng.SetRetryPolicy("old", FixedRetry(3, time.Hour))
+	file := `{"version": 1, "partition": "old", "time": "2025-01-01T00:00:00Z", "past_policy": "clamp", "events": [
+		{"type": "engine_test.countedEvent", "time": "2025-01-01T01:00:00Z",
+		 "data": {"At": "2025-01-01T01:00:00Z", "Label": "Charge", "Partition": "old", "Retry": 3}}]}`
+
+	if _, err := eng.Restore(strings.NewReader(file)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := eng.Step(context.Background(), "old", 1); err != nil {
+		t.Fatal(err)
+	}
+	if letters, _ := eng.DeadLetters("old"); len(letters) != 1 || letters[0].Attempts != 4 {
+		t.Errorf("Expected the retry count of the version 1 event to carry over, got %+v", letters)
+	}
+}
+
+func TestRetryPolicy_DefaultAppliesToNewPartitions(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	policy := LinearRetry(3, time.Hour)
+	eng := NewEngine(nil)
+	eng.RegisterPartition("existing", clock.NewTestClock(start))
+
+	if err := eng.SetDefaultRetryPolicy(RetryPolicy{MaxRetries: -1}); err == nil {
+		t.Error("Expected an invalid default policy to be rejected")
+	}
+	if err := eng.SetDefaultRetryPolicy(policy); err != nil {
+		t.Fatal(err)
+	}
+
+	eng.RegisterPartition("registered", clock.NewTestClock(start))
+	eng.Schedule(&persistentEvent{At: start, Label: "Imported", Partition: "lazy"})
+	file := `{"version": 1, "partition": "old", "time": "2025-01-01T00:00:00Z", "past_policy": "clamp", "events": []}`
+	if _, err := eng.Restore(strings.NewReader(file)); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"registered", "lazy", "old"} {
+		if got := eng.state(id).getRetryPolicy(); got != policy {
+			t.Errorf("Expected partition %s to get the default policy, got %+v", id, got)
+		}
+	}
+	for _, id := range []string{"existing", "SYSTEM"} {
+		if got := eng.state(id).getRetryPolicy(); got != (RetryPolicy{}) {
+			t.Errorf("Expected partition %s to keep its policy, got %+v", id, got)
+		}
+	}
+
+	// a version 2 snapshot carries its own policy, even the zero one
+	var buf bytes.Buffer
+	eng.SetRetryPolicy("registered", RetryPolicy{})
+	eng.Snapshot("registered", &buf)
+	eng.DeletePartition("registered")
+	if _, err := eng.Restore(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if got := eng.state("registered").getRetryPolicy(); got != (RetryPolicy{}) {
+		t.Errorf("Expected the snapshot's policy to win over the default, got %+v", got)
+	}
+}
+
+func TestRetryPolicy_Delays(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		policy RetryPolicy
+		want   []time.Duration
+	}{
+		{"fixed", FixedRetry(3, time.Minute), []time.Duration{time.Minute, time.Minute, time.Minute}},
+		{"linear", LinearRetry(3, time.Minute), []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute}},
+		{"exponential", ExponentialRetry(5, time.Minute, 5*time.Minute),
+			[]time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for i, want := range tc.want {
+				if got := tc.policy.delay(i+1, "seed"); got != want {
+					t.Errorf("delay(%d) = %s, want %s", i+1, got, want)
+				}
+			}
+		})
+	}
+
+	jittered := FixedRetry(1, time.Hour).WithJitter(0.5)
+	a, b := jittered.delay(1, "cust_a/Charge"), jittered.delay(1, "cust_b/Charge")
+	if a != jittered.delay(1, "cust_a/Charge") {
+		t.Error("Expected the jitter to be deterministic")
+	}
+	if a == b || a < 30*time.Minute || a > 90*time.Minute || b < 30*time.Minute || b > 90*time.Minute {
+		t.Errorf("Expected distinct delays within ±50%% of an hour, got %s and %s", a, b)
+	}
+
+	if err := NewEngine(nil).SetRetryPolicy("x", FixedRetry(1, time.Hour).WithJitter(2)); err == nil {
+		t.Error("Expected a jitter above 1 to be rejected")
+	}
+}
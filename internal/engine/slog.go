@@ -0,0 +1,108 @@
+package engine
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// SlogLogger implements the Diagnostic interface with structured log/slog
+// records, so engine activity goes through a service's usual logging
+// pipeline. Every record carries the partition ID, the partition's virtual
+// time and the wall-clock time; event records also carry the event name.
+//
+// As an EngineObserver it also logs lifecycle changes, scheduling and
+// failures, with event handles. Failures are logged at slog.LevelError.
+type SlogLogger struct {
+	// Level is the level of every record. It defaults to slog.LevelInfo;
+	// set it before the engine starts using the logger.
+	Level slog.Level
+
+	logger *slog.Logger
+}
+
+// NewSlogLogger returns a SlogLogger writing to logger, or to slog.Default()
+// if logger is nil.
+func NewSlogLogger(logger *slog.Logger) *SlogLogger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &SlogLogger{Level: slog.LevelInfo, logger: logger}
+}
+
+// OnAdvanceStart logs the start of a causal walk.
+func (s *SlogLogger) OnAdvanceStart(id string, start, target time.Time) {
+	s.log("advance started", id, start,
+		slog.Time("target_time", target))
+}
+
+// OnEventExecute logs an executed event.
+func (s *SlogLogger) OnEventExecute(id string, eventName string, t time.Time) {
+	s.log("event executed", id, t,
+		slog.String("event_name", eventName))
+}
+
+// OnEventCreated logs a causal event produced by an executed one.
+func (s *SlogLogger) OnEventCreated(id string, eventName string, eventTime time.Time, currentTime time.Time) {
+	s.log("event created", id, currentTime,
+		slog.String("event_name", eventName),
+		slog.Time("scheduled_for", eventTime))
+}
+
+// OnAdvanceFinish logs the end of a causal walk.
+func (s *SlogLogger) OnAdvanceFinish(id string, current time.Time) {
+	s.log("advance finished", id, current)
+}
+
+// OnEngineEvent logs what the four hooks above do not already cover.
+func (s *SlogLogger) OnEngineEvent(event EngineEvent) {
+	switch event.Kind {
+	case EngineEventExecuted, EngineEventCreated, EngineAdvanceStarted:
+		return
+	case EngineAdvanceFinished:
+		if event.Err == nil {
+			return
+		}
+	}
+
+	level := s.Level
+	attrs := []slog.Attr{
+		slog.String("partition_id", event.PartitionID),
+		slog.Time("virtual_time", event.VirtualTime),
+		slog.Time("wall_time", event.WallTime),
+	}
+	if event.EventID != 0 {
+		attrs = append(attrs, slog.Uint64("event_id", uint64(event.EventID)))
+	}
+	if event.ParentID != 0 {
+		attrs = append(attrs, slog.Uint64("parent_id", uint64(event.ParentID)))
+	}
+	if event.EventName != "" {
+		attrs = append(attrs, slog.String("event_name", event.EventName), slog.Time("event_time", event.EventTime))
+	}
+	if event.Checkpoint != "" {
+		attrs = append(attrs, slog.String("checkpoint", event.Checkpoint))
+	}
+	if event.Kind == EngineWorkerTick {
+		attrs = append(attrs, slog.Int("executed", event.Executed))
+	}
+	if event.Err != nil {
+		level = slog.LevelError
+		attrs = append(attrs, slog.String("error", event.Err.Error()))
+	}
+	s.logger.LogAttrs(context.Background(), level, strings.ReplaceAll(string(event.Kind), "_", " "), attrs...)
+}
+
+func (s *SlogLogger) log(msg string, id string, virtual time.Time, attrs ...slog.Attr) {
+	ctx := context.Background()
+	if !s.logger.Enabled(ctx, s.Level) {
+		return
+	}
+	attrs = append([]slog.Attr{
+		slog.String("partition_id", id),
+		slog.Time("virtual_time", virtual),
+		slog.Time("wall_time", time.Now()),
+	}, attrs...)
+	s.logger.LogAttrs(ctx, s.Level, msg, attrs...)
+}
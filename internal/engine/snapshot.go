@@ -0,0 +1,236 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/aaryansingh-dev/hybrid-logical-time-go/internal/clock"
+)
+
+// SnapshotVersion is the version of the file format written by Snapshot.
+// Restore also reads version 1, which predates retry policies; partitions
+// restored from it get the engine's default policy, and events that kept their own retry count
+// hand it over through RetryCounter. Other versions are refused.
+const SnapshotVersion = 2
+
+// snapshotFile is the on-disk layout of a partition snapshot.
+type snapshotFile struct {
+	Version     int            `json:"version"`
+	Partition   string         `json:"partition"`
+	Time        time.Time      `json:"time"`
+	PastPolicy  PastPolicy     `json:"past_policy"`
+	RetryPolicy RetryPolicy    `json:"retry_policy"`
+	Frozen      bool           `json:"frozen"`
+	Events      []EncodedEvent `json:"events"` // pending events in execution order
+
+	// Attempts holds the failed attempts of the pending events being
+	// retried, keyed by their index in Events, since event handles are not
+	// kept across a restore.
+	Attempts    map[int]int      `json:"attempts,omitempty"`
+	DeadLetters []snapshotLetter `json:"dead_letters,omitempty"` // oldest first
+}
+
+// snapshotLetter is a dead letter in a snapshot.
+type snapshotLetter struct {
+	Event    EncodedEvent `json:"event"`
+	Name     string       `json:"name"`
+	Time     time.Time    `json:"time"`
+	Attempts int          `json:"attempts"`
+	Err      string       `json:"error,omitempty"`
+}
+
+// Snapshot writes a partition's clock time, settings and full pending heap to
+// w as versioned JSON, together with the retry counts of pending events and
+// the dead-letter queue. Every pending and dead-lettered event must be of a
+// type registered in the engine's Registry. If a walk is in progress,
+// Snapshot waits for it to finish so the snapshot is always taken between
+// events.
+func (engine *Engine) Snapshot(partitionID string, w io.Writer) error {
+	if partitionID == "SYSTEM" {
+		return fmt.Errorf("invalid operation: the SYSTEM partition follows wall-clock time and cannot be snapshotted")
+	}
+
+	state, err := engine.lockPartition(partitionID)
+	if err != nil {
+		return err
+	}
+	defer state.unlockWalk()
+
+	queue, provider, err := engine.getPartition(partitionID)
+	if err != nil {
+		return err
+	}
+	if _, ok := provider.(*clock.TestClock); !ok {
+		return fmt.Errorf("partition %s is not a TestClock; only simulation partitions can be snapshotted", partitionID)
+	}
+
+	items := queue.ordered()
+	codec := engine.getCodec()
+
+	state.mu.Lock()
+	file := snapshotFile{
+		Version:     SnapshotVersion,
+		Partition:   partitionID,
+		Time:        provider.Now(),
+		PastPolicy:  state.pastPolicy,
+		RetryPolicy: state.retryPolicy,
+		Frozen:      state.frozen,
+		Events:      []EncodedEvent{},
+	}
+	attempts := maps.Clone(state.attempts)
+	letters := slices.Clone(state.deadLetters)
+	state.mu.Unlock()
+
+	for i, item := range items {
+		encoded, err := codec.Encode(item.Event)
+		if err != nil {
+			return fmt.Errorf("snapshot partition %s: %w", partitionID, err)
+		}
+		file.Events = append(file.Events, encoded)
+		if n := attempts[item.ID]; n > 0 {
+			if file.Attempts == nil {
+				file.Attempts = make(map[int]int)
+			}
+			file.Attempts[i] = n
+		}
+	}
+	for _, letter := range letters {
+		encoded, err := codec.Encode(letter.event)
+		if err != nil {
+			return fmt.Errorf("snapshot partition %s: dead letter %d: %w", partitionID, letter.ID, err)
+		}
+		saved := snapshotLetter{Event: encoded, Name: letter.Name, Time: letter.Time, Attempts: letter.Attempts}
+		if letter.Err != nil {
+			saved.Err = letter.Err.Error()
+		}
+		file.DeadLetters = append(file.DeadLetters, saved)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(file)
+}
+
+// Restore reads a snapshot written by Snapshot and registers it as a new
+// partition with a TestClock, returning the partition's ID. The partition
+// must not already be registered. Pending events are re-queued in their
+// original execution order, under new handles; retry counts and dead letters
+// follow them. A dead letter's error comes back as its message only.
+func (engine *Engine) Restore(r io.Reader) (string, error) {
+	var file snapshotFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return "", fmt.Errorf("restore snapshot: %w", err)
+	}
+	if file.Version != SnapshotVersion && file.Version != 1 {
+		return "", fmt.Errorf("restore snapshot: unsupported version %d (want %d)", file.Version, SnapshotVersion)
+	}
+	switch file.Partition {
+	case "":
+		return "", fmt.Errorf("restore snapshot: missing partition id")
+	case "SYSTEM":
+		return "", fmt.Errorf("restore snapshot: invalid operation: SYSTEM is a reserved partition id")
+	}
+	if err := file.RetryPolicy.validate(); err != nil {
+		return "", fmt.Errorf("restore snapshot: %w", err)
+	}
+
+	// decode everything before touching the engine, so a bad file leaves no half-restored partition
+	codec := engine.getCodec()
+	events := make([]Event, 0, len(file.Events))
+	for i, encoded := range file.Events {
+		event, err := codec.Decode(encoded)
+		if err != nil {
+			return "", fmt.Errorf("restore snapshot: event %d: %w", i, err)
+		}
+		if event.ClockID() != file.Partition {
+			return "", fmt.Errorf("restore snapshot: event %d belongs to partition %s, not %s", i, event.ClockID(), file.Partition)
+		}
+		events = append(events, event)
+	}
+	for i, n := range file.Attempts {
+		if i < 0 || i >= len(events) || n < 0 {
+			return "", fmt.Errorf("restore snapshot: invalid retry count %d for event %d", n, i)
+		}
+	}
+	if file.Version == 1 {
+		// version 1 events kept their own retry count
+		for i, event := range events {
+			if counter, ok := event.(RetryCounter); ok && counter.RetryCount() > 0 {
+				if file.Attempts == nil {
+					file.Attempts = make(map[int]int)
+				}
+				file.Attempts[i] = counter.RetryCount()
+			}
+		}
+	}
+	letters := make([]DeadLetter, len(file.DeadLetters))
+	for i, saved := range file.DeadLetters {
+		event, err := codec.Decode(saved.Event)
+		if err != nil {
+			return "", fmt.Errorf("restore snapshot: dead letter %d: %w", i, err)
+		}
+		if event.ClockID() != file.Partition {
+			return "", fmt.Errorf("restore snapshot: dead letter %d belongs to partition %s, not %s", i, event.ClockID(), file.Partition)
+		}
+		letters[i] = DeadLetter{Name: saved.Name, Time: saved.Time, Attempts: saved.Attempts, event: event}
+		if saved.Err != "" {
+			letters[i].Err = errors.New(saved.Err)
+		}
+	}
+
+	engine.mu.Lock()
+	_, hasClock := engine.clocks[file.Partition]
+	_, hasQueue := engine.queues[file.Partition]
+	if hasClock || hasQueue {
+		engine.mu.Unlock()
+		return "", fmt.Errorf("restore snapshot: partition %s: %w", file.Partition, ErrPartitionExists)
+	}
+	queue, err := engine.newQueueLocked(file.Partition)
+	if err != nil {
+		engine.mu.Unlock()
+		return "", err
+	}
+	ids := make([]EventID, len(events))
+	for i, event := range events {
+		ids[i] = newEventID()
+		if err := queue.push(ids[i], event); err != nil {
+			engine.mu.Unlock()
+			queue.close()
+			return "", fmt.Errorf("restore snapshot: %w", err)
+		}
+	}
+	engine.queues[file.Partition] = queue
+	engine.clocks[file.Partition] = clock.NewTestClock(file.Time)
+	engine.mu.Unlock()
+	for i := range letters {
+		letters[i].ID = newEventID()
+	}
+
+	state := engine.state(file.Partition)
+	state.mu.Lock()
+	state.pastPolicy = file.PastPolicy
+	if file.Version > 1 {
+		state.retryPolicy = file.RetryPolicy
+	}
+	state.frozen = file.Frozen
+	state.attempts = nil
+	for i, n := range file.Attempts {
+		if n == 0 {
+			continue
+		}
+		if state.attempts == nil {
+			state.attempts = make(map[EventID]int)
+		}
+		state.attempts[ids[i]] = n
+	}
+	state.deadLetters = letters
+	state.mu.Unlock()
+
+	engine.observeLoaded(file.Partition, file.Time, ids, events)
+	return file.Partition, nil
+}
@@ -0,0 +1,152 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aaryansingh-dev/hybrid-logical-time-go/internal/clock"
+)
+
+// persistentEvent is a MockEvent that can be snapshotted: all of its state
+// is in exported fields
+type persistentEvent struct {
+	At        time.Time
+	Label     string
+	Partition string
+}
+
+func (e *persistentEvent) Time() time.Time                       { return e.At }
+func (e *persistentEvent) Name() string                          { return e.Label }
+func (e *persistentEvent) ClockID() string                       { return e.Partition }
+func (e *persistentEvent) Execute(tp clock.TimeProvider) []Event { return nil }
+
+func init() {
+	RegisterEventType("engine_test.persistentEvent", func() Event { return &persistentEvent{} })
+}
+
+func TestEngine_SnapshotRestore_RoundTrip(t *testing.T) {
+	forEachStore(t, func(t *testing.T, factory QueueFactory) {
+		src := NewEngine(nil)
+		src.SetQueueFactory(factory)
+		id := "dunning_fixture"
+		start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+		src.RegisterPartition(id, clock.NewTestClock(start))
+		src.SetPastPolicy(id, PastReject)
+		src.SetRetryPolicy(id, ExponentialRetry(5, time.Hour, 24*time.Hour).WithJitter(0.1))
+
+		at := start.Add(time.Hour)
+		src.Schedule(&persistentEvent{At: at.Add(time.Hour), Label: "Later", Partition: id})
+		src.Schedule(&persistentEvent{At: at, Label: "TieA", Partition: id})
+		moved, _ := src.Schedule(&persistentEvent{At: at, Label: "Moved", Partition: id})
+		src.Schedule(&persistentEvent{At: at, Label: "TieB", Partition: id})
+		src.Reschedule(moved, at.Add(30*time.Minute))
+
+		var buf bytes.Buffer
+		if err := src.Snapshot(id, &buf); err != nil {
+			t.Fatalf("Snapshot failed: %v", err)
+		}
+
+		dst := NewEngine(nil)
+		restored, err := dst.Restore(&buf)
+		if err != nil {
+			t.Fatalf("Restore failed: %v", err)
+		}
+		if restored != id {
+			t.Errorf("Restored partition %s, want %s", restored, id)
+		}
+
+		want, _ := src.ListPendingEvents(id)
+		got, _ := dst.ListPendingEvents(id)
+		if len(got) != len(want) {
+			t.Fatalf("Restored %d events, want %d", len(got), len(want))
+		}
+		for i := range want {
+			if got[i].Name != want[i].Name || !got[i].Time.Equal(want[i].Time) {
+				t.Errorf("Event %d: got %s at %v, want %s at %v", i, got[i].Name, got[i].Time, want[i].Name, want[i].Time)
+			}
+		}
+
+		status, _ := dst.Status(id)
+		if status.Provider != ProviderTestClock || !status.Now.Equal(start) {
+			t.Errorf("Expected restored test clock at %v, got %+v", start, status)
+		}
+		if _, err := dst.Schedule(&persistentEvent{At: start.Add(-time.Hour), Label: "Past", Partition: id}); !errors.Is(err, ErrEventInPast) {
+			t.Errorf("Expected restored partition to keep its past policy, got %v", err)
+		}
+		if got, want := dst.state(id).getRetryPolicy(), src.state(id).getRetryPolicy(); got != want {
+			t.Errorf("Expected restored partition to keep its retry policy %+v, got %+v", want, got)
+		}
+
+		// the restored partition is fully functional
+		if err := dst.Advance(context.Background(), id, start.Add(3*time.Hour)); err != nil {
+			t.Errorf("Advance on restored partition failed: %v", err)
+		}
+	})
+}
+
+func TestEngine_Restore_Errors(t *testing.T) {
+	eng := NewEngine(nil)
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	eng.RegisterPartition("taken", clock.NewTestClock(start))
+
+	tests := []struct {
+		name    string
+		file    string
+		wantErr string
+	}{
+		{"bad version", `{"version": 99, "partition": "p", "events": []}`, "unsupported version"},
+		{"unknown type", `{"version": 1, "partition": "p", "events": [{"type": "nope", "data": {}}]}`, "unknown event type"},
+		{"existing partition", `{"version": 1, "partition": "taken", "events": []}`, "already registered"},
+		{"system partition", `{"version": 2, "partition": "SYSTEM", "events": []}`, "reserved partition id"},
+		{"missing partition", `{"version": 2, "events": []}`, "missing partition id"},
+		{"foreign event", `{"version": 1, "partition": "p", "events": [{"type": "engine_test.persistentEvent", "data": {"Partition": "other"}}]}`, "belongs to partition other"},
+		{"bad retry policy", `{"version": 2, "partition": "p", "retry_policy": {"backoff": "fixed", "jitter": 3}, "events": []}`, "jitter"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := eng.Restore(strings.NewReader(tt.file))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	if _, err := eng.Status("p"); err == nil {
+		t.Error("A failed restore must not leave a partition behind")
+	}
+	if _, err := eng.Status(""); err == nil {
+		t.Error("A failed restore must not leave a partition behind")
+	}
+	if status, _ := eng.Status("SYSTEM"); status.Provider != ProviderRealTime {
+		t.Errorf("Expected SYSTEM to stay on real time, got %s", status.Provider)
+	}
+}
+
+func TestEngine_Restore_Version1(t *testing.T) {
+	eng := NewEngine(nil)
+	file := `{"version": 1, "partition": "old", "time": "2025-01-01T00:00:00Z", "past_policy": "clamp", "events": []}`
+
+	if _, err := eng.Restore(strings.NewReader(file)); err != nil {
+		t.Fatalf("Expected a version 1 snapshot to restore, got %v", err)
+	}
+	if policy := eng.state("old").getRetryPolicy(); policy != (RetryPolicy{}) {
+		t.Errorf("Expected no retry policy from a version 1 snapshot, got %+v", policy)
+	}
+}
+
+func TestEngine_Snapshot_UnregisteredEvent(t *testing.T) {
+	eng := NewEngine(nil)
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	eng.RegisterPartition("p", clock.NewTestClock(start))
+	eng.Schedule(&MockEvent{executionTime: start, name: "Opaque", clockID: "p"})
+
+	var buf bytes.Buffer
+	if err := eng.Snapshot("p", &buf); !errors.Is(err, ErrUnknownEventType) {
+		t.Errorf("Expected ErrUnknownEventType, got %v", err)
+	}
+}
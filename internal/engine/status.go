@@ -0,0 +1,166 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aaryansingh-dev/hybrid-logical-time-go/internal/clock"
+)
+
+// ProviderKind names the kind of TimeProvider behind a partition.
+type ProviderKind string
+
+const (
+	ProviderTestClock ProviderKind = "test_clock" // virtual time, moved by Advance
+	ProviderRealTime  ProviderKind = "real_time"  // wall-clock time
+	ProviderCustom    ProviderKind = "custom"     // any other TimeProvider implementation
+	ProviderNone      ProviderKind = "none"       // lazily created by Schedule, no clock registered yet
+)
+
+func providerKind(provider clock.TimeProvider) ProviderKind {
+	switch provider.(type) {
+	case nil:
+		return ProviderNone
+	case *clock.TestClock:
+		return ProviderTestClock
+	case *clock.RealTimeProvider:
+		return ProviderRealTime
+	default:
+		return ProviderCustom
+	}
+}
+
+// AdvanceStats describes the most recent causal walk of a partition, whether
+// it came from Advance or one of the stepping APIs.
+type AdvanceStats struct {
+	From      time.Time     // virtual time when the walk started
+	To        time.Time     // virtual time when the walk stopped
+	Executed  int           // events executed
+	StartedAt time.Time     // wall-clock start
+	Duration  time.Duration // wall-clock duration
+}
+
+// PartitionStatus is a point-in-time view of a partition.
+type PartitionStatus struct {
+	ID       string
+	Provider ProviderKind
+	Now      time.Time // current time of the partition's clock; zero if it has none
+	Pending  int
+
+	// NextEventTime and NextEventName describe the event that will execute
+	// next. Both are zero values when nothing is pending.
+	NextEventTime time.Time
+	NextEventName string
+
+	// LastAdvance is nil until the partition has been walked at least once.
+	LastAdvance *AdvanceStats
+
+	Frozen bool
+}
+
+// PendingEvent is an immutable view of an event waiting in a partition's heap.
+type PendingEvent struct {
+	ID       EventID
+	Name     string
+	Time     time.Time
+	Priority int
+}
+
+// Status returns the current status of a single partition, including SYSTEM.
+func (engine *Engine) Status(partitionID string) (PartitionStatus, error) {
+	if partitionID == "SYSTEM" {
+		return engine.systemStatus(), nil
+	}
+
+	engine.mu.RLock()
+	queue, qOk := engine.queues[partitionID]
+	provider := engine.clocks[partitionID]
+	state := engine.partitions[partitionID]
+	engine.mu.RUnlock()
+
+	if !qOk {
+		return PartitionStatus{}, fmt.Errorf("partition %s not found", partitionID)
+	}
+	return partitionStatus(partitionID, queue, provider, state), nil
+}
+
+// Statuses returns the status of every partition, including SYSTEM and
+// partitions created lazily by Schedule, ordered by partition ID.
+func (engine *Engine) Statuses() []PartitionStatus {
+	engine.mu.RLock()
+	statuses := make([]PartitionStatus, 0, len(engine.queues)+1)
+	for id, queue := range engine.queues {
+		statuses = append(statuses, partitionStatus(id, queue, engine.clocks[id], engine.partitions[id]))
+	}
+	engine.mu.RUnlock()
+
+	statuses = append(statuses, engine.systemStatus())
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].ID < statuses[j].ID
+	})
+	return statuses
+}
+
+// ListPendingEvents returns the pending events of a partition in the exact
+// order they will execute. The result is a copy; later scheduling does not
+// change it.
+func (engine *Engine) ListPendingEvents(partitionID string) ([]PendingEvent, error) {
+	if partitionID == "SYSTEM" {
+		return engine.systemQueue.pending(), nil
+	}
+
+	engine.mu.RLock()
+	queue, ok := engine.queues[partitionID]
+	engine.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("partition %s not found", partitionID)
+	}
+	return queue.pending(), nil
+}
+
+func (engine *Engine) systemStatus() PartitionStatus {
+	return partitionStatus("SYSTEM", engine.systemQueue, clock.NewRealTimeProvider(), nil)
+}
+
+func partitionStatus(id string, queue *EventQueue, provider clock.TimeProvider, state *partitionState) PartitionStatus {
+	status := PartitionStatus{
+		ID:       id,
+		Provider: providerKind(provider),
+	}
+	if provider != nil {
+		status.Now = provider.Now()
+	}
+
+	next, pending := queue.head()
+	status.Pending = pending
+	if next != nil {
+		status.NextEventTime = next.Time()
+		status.NextEventName = next.Name()
+	}
+
+	if state != nil {
+		status.LastAdvance = state.getLastAdvance()
+		status.Frozen = state.isFrozen()
+	}
+	return status
+}
+
+// GetStatus returns a snapshot of all registered partitions.
+// The resulting map contains human-readable status strings including current
+// logical time and pending event counts for each partition.
+//
+// Deprecated: use Statuses, which returns structured values instead of
+// strings that have to be parsed.
+func (engine *Engine) GetStatus() map[string]string {
+	status := make(map[string]string)
+	for _, s := range engine.Statuses() {
+		now := "-"
+		if !s.Now.IsZero() {
+			now = s.Now.Format("2006-01-02 15:04:05")
+		}
+		status[s.ID] = fmt.Sprintf("Time: %s | Pending Events: %d", now, s.Pending)
+	}
+	return status
+}
@@ -0,0 +1,199 @@
+package engine
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// QueuedEvent is a scheduled event together with the keys used to order it.
+// Time alone is not enough: events sharing a timestamp must still pop in a
+// reproducible order, so every push is stamped with a priority and an
+// insertion sequence by the EventQueue.
+type QueuedEvent struct {
+	ID       EventID
+	Event    Event
+	Priority int    // explicit tie-break rank, see Prioritized
+	Seq      uint64 // insertion order, the final tie-breaker
+}
+
+// Before reports whether q executes before other.
+func (q QueuedEvent) Before(other QueuedEvent) bool {
+	return executesBefore(q.Event.Time(), q.Priority, q.Seq, other.Event.Time(), other.Priority, other.Seq)
+}
+
+// executesBefore orders by time, then by descending priority, then by
+// insertion sequence. The sequence is unique per queue, so no two events
+// compare equal and the pop order never depends on a store's internal layout.
+func executesBefore(aTime time.Time, aPriority int, aSeq uint64, bTime time.Time, bPriority int, bSeq uint64) bool {
+	if !aTime.Equal(bTime) {
+		return aTime.Before(bTime)
+	}
+	if aPriority != bPriority {
+		return aPriority > bPriority
+	}
+	return aSeq < bSeq
+}
+
+// QueueStore holds the pending events behind an EventQueue. Implementations
+// must keep events in the order defined by QueuedEvent.Before. They do not
+// need to be safe for concurrent use: the EventQueue serializes every call.
+//
+// A store may additionally implement Contains(EventID) bool and Clear() error
+// to avoid the slower fallbacks built on Iterate and Pop, and io.Closer to
+// release its resources when the partition is deleted.
+type QueueStore interface {
+	Push(item QueuedEvent) error
+	Pop() (QueuedEvent, bool, error)
+	Peek() (QueuedEvent, bool, error)
+	Remove(id EventID) (QueuedEvent, bool, error)
+	Len() int
+	// Iterate calls fn for every pending event in execution order until fn
+	// returns false.
+	Iterate(fn func(QueuedEvent) bool) error
+}
+
+// QueueFactory creates the store for a partition's queue. It lets the engine
+// keep some or all partitions somewhere other than memory.
+type QueueFactory func(partitionID string) (QueueStore, error)
+
+// heapItem is a QueuedEvent placed in a HeapStore.
+type heapItem struct {
+	QueuedEvent
+	index int // position in the heap, maintained by Swap/Push so items can be removed
+}
+
+// eventHeap is the raw container/heap implementation behind HeapStore.
+type eventHeap []*heapItem
+
+func (h eventHeap) Len() int {
+	return len(h)
+}
+
+func (h eventHeap) Less(i, j int) bool {
+	return h[i].Before(h[j].QueuedEvent)
+}
+
+func (h eventHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *eventHeap) Push(x interface{}) {
+	item := x.(*heapItem) // heap hands us an interface{}, but we know only heapItems go in here.
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *eventHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil // let the GC reclaim the popped item
+	item.index = -1
+	*h = old[:n-1]
+	return item
+}
+
+// HeapStore is the default QueueStore: an in-memory min-heap. It never fails
+// and keeps events as they were pushed, so it works with any Event type.
+type HeapStore struct {
+	events eventHeap
+	byID   map[EventID]*heapItem // handle lookup for Remove
+}
+
+// NewHeapStore returns an empty in-memory store.
+func NewHeapStore() *HeapStore {
+	return &HeapStore{byID: make(map[EventID]*heapItem)}
+}
+
+func (s *HeapStore) Push(item QueuedEvent) error {
+	entry := &heapItem{QueuedEvent: item}
+	heap.Push(&s.events, entry)
+	s.byID[item.ID] = entry
+	return nil
+}
+
+func (s *HeapStore) Pop() (QueuedEvent, bool, error) {
+	if len(s.events) == 0 {
+		return QueuedEvent{}, false, nil
+	}
+	entry := heap.Pop(&s.events).(*heapItem)
+	delete(s.byID, entry.ID)
+	return entry.QueuedEvent, true, nil
+}
+
+func (s *HeapStore) Peek() (QueuedEvent, bool, error) {
+	if len(s.events) == 0 {
+		return QueuedEvent{}, false, nil
+	}
+	return s.events[0].QueuedEvent, true, nil
+}
+
+func (s *HeapStore) Remove(id EventID) (QueuedEvent, bool, error) {
+	entry, ok := s.byID[id]
+	if !ok {
+		return QueuedEvent{}, false, nil
+	}
+	heap.Remove(&s.events, entry.index)
+	delete(s.byID, id)
+	return entry.QueuedEvent, true, nil
+}
+
+func (s *HeapStore) Len() int {
+	return len(s.events)
+}
+
+func (s *HeapStore) Contains(id EventID) bool {
+	_, ok := s.byID[id]
+	return ok
+}
+
+func (s *HeapStore) Clear() error {
+	s.events = nil
+	s.byID = make(map[EventID]*heapItem)
+	return nil
+}
+
+// Iterate walks a sorted copy, so the heap itself is left undisturbed.
+func (s *HeapStore) Iterate(fn func(QueuedEvent) bool) error {
+	items := make([]QueuedEvent, len(s.events))
+	for i, entry := range s.events {
+		items[i] = entry.QueuedEvent
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Before(items[j]) })
+
+	for _, item := range items {
+		if !fn(item) {
+			break
+		}
+	}
+	return nil
+}
+
+// SetQueueFactory sets how queues are created for partitions registered from
+// now on; existing partitions keep their stores. A nil factory restores the
+// default in-memory HeapStore. The SYSTEM queue is always kept in memory.
+func (engine *Engine) SetQueueFactory(factory QueueFactory) {
+	engine.mu.Lock()
+	defer engine.mu.Unlock()
+
+	engine.queueFactory = factory
+}
+
+// newQueueLocked creates the queue for a partition, and its settings if it
+// has none yet, so that they are taken from the defaults in force when the
+// partition came into existence. The caller must hold engine.mu.
+func (engine *Engine) newQueueLocked(partitionID string) (*EventQueue, error) {
+	engine.stateLocked(partitionID)
+	if engine.queueFactory == nil {
+		return NewEventQueue(), nil
+	}
+	store, err := engine.queueFactory(partitionID)
+	if err != nil {
+		return nil, fmt.Errorf("create queue for partition %s: %w", partitionID, err)
+	}
+	return NewEventQueueWithStore(store), nil
+}
@@ -0,0 +1,314 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aaryansingh-dev/hybrid-logical-time-go/internal/clock"
+)
+
+// rankedEvent is a persistentEvent with an explicit priority
+type rankedEvent struct {
+	persistentEvent
+	Rank int
+}
+
+func (e *rankedEvent) Priority() int { return e.Rank }
+
+func init() {
+	RegisterEventType("engine_test.rankedEvent", func() Event { return &rankedEvent{} })
+}
+
+// storeFactories lists every QueueStore implementation; the tests below run
+// against each of them.
+func storeFactories(t *testing.T) map[string]QueueFactory {
+	dir := t.TempDir()
+	return map[string]QueueFactory{
+		"heap": func(string) (QueueStore, error) { return NewHeapStore(), nil },
+		"file": FileStoreFactory(dir, NewCodec(DefaultRegistry)),
+	}
+}
+
+// forEachStore runs test once per QueueStore implementation. Engine tests
+// whose events are all registered use it, so they cover the file store too.
+func forEachStore(t *testing.T, test func(t *testing.T, factory QueueFactory)) {
+	for name, factory := range storeFactories(t) {
+		t.Run(name, func(t *testing.T) { test(t, factory) })
+	}
+}
+
+func newStoreQueue(t *testing.T, factory QueueFactory) *EventQueue {
+	t.Helper()
+
+	store, err := factory(strings.ReplaceAll(t.Name(), "/", "_"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	queue := NewEventQueueWithStore(store)
+	t.Cleanup(func() { queue.close() })
+	return queue
+}
+
+func TestQueueStore_Ordering(t *testing.T) {
+	at := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for name, factory := range storeFactories(t) {
+		t.Run(name, func(t *testing.T) {
+			q := newStoreQueue(t, factory)
+			q.PushEvent(&persistentEvent{At: at, Label: "Default1"})
+			q.PushEvent(&rankedEvent{persistentEvent{At: at, Label: "Low"}, -1})
+			q.PushEvent(&rankedEvent{persistentEvent{At: at, Label: "High"}, 10})
+			q.PushEvent(&persistentEvent{At: at, Label: "Default2"})
+			q.PushEvent(&persistentEvent{At: at.Add(-time.Second), Label: "Earlier"})
+
+			want := []string{"Earlier", "High", "Default1", "Default2", "Low"}
+			for i, event := range q.pending() {
+				if event.Name != want[i] {
+					t.Errorf("pending[%d] = %s, want %s", i, event.Name, want[i])
+				}
+			}
+			for _, name := range want {
+				if got := q.Peek().Name(); got != name {
+					t.Errorf("Peek() = %s, want %s", got, name)
+				}
+				if got := q.PopEvent().Name(); got != name {
+					t.Errorf("PopEvent() = %s, want %s", got, name)
+				}
+			}
+			if q.Len() != 0 || q.PopEvent() != nil || q.Err() != nil {
+				t.Errorf("Expected an empty, healthy queue, got %d events and err %v", q.Len(), q.Err())
+			}
+		})
+	}
+}
+
+func TestQueueStore_RemoveRescheduleClear(t *testing.T) {
+	at := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for name, factory := range storeFactories(t) {
+		t.Run(name, func(t *testing.T) {
+			q := newStoreQueue(t, factory)
+			first, _ := q.PushEvent(&persistentEvent{At: at, Label: "First"})
+			second, _ := q.PushEvent(&persistentEvent{At: at.Add(time.Hour), Label: "Second"})
+			third, _ := q.PushEvent(&persistentEvent{At: at.Add(2 * time.Hour), Label: "Third"})
+
+			if event, ok := q.Remove(second); !ok || event.Name() != "Second" {
+				t.Errorf("Remove(second) = %v, %v", event, ok)
+			}
+			if q.Contains(second) {
+				t.Error("Expected the removed event to be gone")
+			}
+			if !q.Reschedule(first, at.Add(3*time.Hour)) {
+				t.Fatal("Reschedule failed")
+			}
+
+			pending := q.pending()
+			if len(pending) != 2 || pending[0].ID != third || pending[1].ID != first || !pending[1].Time.Equal(at.Add(3*time.Hour)) {
+				t.Errorf("Unexpected queue after remove and reschedule: %+v", pending)
+			}
+
+			q.clear()
+			if q.Len() != 0 || q.Contains(third) {
+				t.Errorf("Expected clear to drop everything, %d left", q.Len())
+			}
+		})
+	}
+}
+
+func TestEngine_RunsAgainstEveryStore(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for name, factory := range storeFactories(t) {
+		t.Run(name, func(t *testing.T) {
+			diag := &MockDiagnostic{}
+			eng := NewEngine(diag)
+			eng.SetQueueFactory(factory)
+			id := "store_" + name
+			eng.RegisterPartition(id, clock.NewTestClock(start))
+
+			eng.Schedule(&persistentEvent{At: start.Add(2 * time.Hour), Label: "Kept", Partition: id})
+			dropped, _ := eng.Schedule(&persistentEvent{At: start.Add(3 * time.Hour), Label: "Dropped", Partition: id})
+			moved, _ := eng.Schedule(&persistentEvent{At: start.Add(4 * time.Hour), Label: "Moved", Partition: id})
+			eng.Schedule(NewCheckpointEvent(start.Add(90*time.Minute), id, "mid"))
+
+			if err := eng.Cancel(dropped); err != nil {
+				t.Fatal(err)
+			}
+			if err := eng.Reschedule(moved, start.Add(30*time.Minute)); err != nil {
+				t.Fatal(err)
+			}
+			if err := eng.Advance(context.Background(), id, start.Add(24*time.Hour)); err != nil {
+				t.Fatal(err)
+			}
+
+			diag.mu.Lock()
+			got := fmt.Sprint(diag.eventsExecuted)
+			diag.mu.Unlock()
+			if want := "[Moved Checkpoint(mid) Kept]"; got != want {
+				t.Errorf("Executed %s, want %s", got, want)
+			}
+
+			if err := eng.Rewind(id, "mid"); err != nil {
+				t.Fatal(err)
+			}
+			if pending, _ := eng.ListPendingEvents(id); len(pending) != 1 || pending[0].Name != "Kept" {
+				t.Errorf("Expected Kept pending after the rewind, got %+v", pending)
+			}
+			if err := eng.DeletePartition(id); err != nil {
+				t.Errorf("DeletePartition failed: %v", err)
+			}
+		})
+	}
+}
+
+func TestFileStore_RejectsUnregisteredEvents(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	eng := NewEngine(nil)
+	eng.SetQueueFactory(FileStoreFactory(t.TempDir(), NewCodec(DefaultRegistry)))
+	eng.RegisterPartition("file", clock.NewTestClock(start))
+
+	if _, err := eng.Schedule(&MockEvent{executionTime: start, name: "Opaque", clockID: "file"}); err == nil {
+		t.Error("Expected scheduling an unregistered event into a file store to fail")
+	}
+
+	// the rejection is the caller's problem, not the partition's
+	eng.Schedule(&persistentEvent{At: start.Add(time.Hour), Label: "Valid", Partition: "file"})
+	if err := eng.Advance(context.Background(), "file", start.Add(2*time.Hour)); err != nil {
+		t.Fatalf("Expected the partition to keep working after a rejected event, got %v", err)
+	}
+}
+
+func TestFileStore_CompactsAndRemovesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "big.queue")
+	store, err := OpenFileStore(path, NewCodec(DefaultRegistry))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := NewEventQueueWithStore(store)
+
+	at := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	padding := strings.Repeat("x", 200)
+	for i := 0; i < 10000; i++ {
+		q.PushEvent(&persistentEvent{At: at.Add(time.Duration(i) * time.Minute), Label: fmt.Sprintf("%05d%s", i, padding)})
+	}
+	full, _ := os.Stat(path)
+
+	for i := 0; i < 9000; i++ {
+		q.PopEvent()
+	}
+	compacted, _ := os.Stat(path)
+	if compacted.Size() >= full.Size()/2 {
+		t.Errorf("Expected the file to shrink below %d bytes, got %d", full.Size()/2, compacted.Size())
+	}
+
+	// the events that survived compaction are still read back in order
+	if next := q.PopEvent(); next == nil || !strings.HasPrefix(next.Name(), "09000") {
+		t.Errorf("Expected event 09000 after compaction, got %v", next)
+	}
+	if q.Err() != nil {
+		t.Fatal(q.Err())
+	}
+
+	if err := q.close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected Close to remove the queue file, got %v", err)
+	}
+}
+
+func TestFileStore_FailedCompactionKeepsEvents(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "queues")
+	os.Mkdir(dir, 0o755)
+	store, err := OpenFileStore(filepath.Join(dir, "big.queue"), NewCodec(DefaultRegistry))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := NewEventQueueWithStore(store)
+
+	at := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	padding := strings.Repeat("x", 200)
+	for i := 0; i < 10000; i++ {
+		q.PushEvent(&persistentEvent{At: at.Add(time.Duration(i) * time.Minute), Label: fmt.Sprintf("%05d%s", i, padding)})
+	}
+
+	// the open file stays usable, but there is nowhere to compact it to
+	os.RemoveAll(dir)
+	for i := 0; i < 9000; i++ {
+		if next := q.PopEvent(); next == nil || !strings.HasPrefix(next.Name(), fmt.Sprintf("%05d", i)) {
+			t.Fatalf("Expected event %05d, got %v (queue error %v)", i, next, q.Err())
+		}
+	}
+	if q.Err() != nil || q.Len() != 1000 {
+		t.Errorf("Expected 1000 events left in a healthy queue, got %d and %v", q.Len(), q.Err())
+	}
+	if store.CompactErr() == nil {
+		t.Error("Expected the failed compaction to be recorded")
+	}
+}
+
+var errStoreUnavailable = errors.New("store unavailable")
+
+// failingStore fails reads while down is set
+type failingStore struct {
+	QueueStore
+	down bool
+}
+
+func (s *failingStore) Peek() (QueuedEvent, bool, error) {
+	if s.down {
+		return QueuedEvent{}, false, errStoreUnavailable
+	}
+	return s.QueueStore.Peek()
+}
+
+func TestEngine_RecoversFromStoreFailure(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	forEachStore(t, func(t *testing.T, factory QueueFactory) {
+		var store *failingStore
+		eng := NewEngine(nil)
+		eng.SetQueueFactory(func(id string) (QueueStore, error) {
+			inner, err := factory(id)
+			store = &failingStore{QueueStore: inner}
+			return store, err
+		})
+		id := "recovering"
+		eng.RegisterPartition(id, clock.NewTestClock(start))
+		eng.Schedule(&persistentEvent{At: start.Add(time.Hour), Label: "Charge", Partition: id})
+		if err := eng.Checkpoint(id, "start"); err != nil {
+			t.Fatal(err)
+		}
+
+		// one transient failure fails the walk that sees it ...
+		store.down = true
+		if err := eng.Advance(context.Background(), id, start.Add(2*time.Hour)); !errors.Is(err, errStoreUnavailable) {
+			t.Fatalf("Expected the store failure, got %v", err)
+		}
+		store.down = false
+
+		// ... until the partition's queue is replaced
+		if err := eng.Rewind(id, "start"); err != nil {
+			t.Fatal(err)
+		}
+		if err := eng.Advance(context.Background(), id, start.Add(2*time.Hour)); err != nil {
+			t.Errorf("Expected the partition to work again after a rewind, got %v", err)
+		}
+
+		store.down = true
+		eng.Advance(context.Background(), id, start.Add(3*time.Hour))
+		store.down = false
+		if err := eng.ResetPartition(id, start); err != nil {
+			t.Fatal(err)
+		}
+		if err := eng.Advance(context.Background(), id, start.Add(time.Hour)); err != nil {
+			t.Errorf("Expected the partition to work again after a reset, got %v", err)
+		}
+	})
+}
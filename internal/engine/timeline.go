@@ -0,0 +1,163 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TimelineEntry is one executed event.
+type TimelineEntry struct {
+	Partition string
+	Name      string
+	Time      time.Time // the partition's virtual time when the event executed
+}
+
+// Timeline is a list of executed events in execution order. Its query
+// methods are meant for test assertions, so that a billing sequence can be
+// checked as "the first payment came 10 minutes after the invoice" rather
+// than by indexing into a slice of names.
+//
+// Methods returning a Timeline narrow it down and can be chained:
+//
+//	timeline.Named("PaymentAttempt").Between(trialEnd, trialEnd.Add(24*time.Hour)).Len()
+type Timeline []TimelineEntry
+
+// Named returns the entries of events with the given name.
+func (tl Timeline) Named(name string) Timeline {
+	var named Timeline
+	for _, entry := range tl {
+		if entry.Name == name {
+			named = append(named, entry)
+		}
+	}
+	return named
+}
+
+// Between returns the entries that executed from `from` to `to`, both
+// inclusive.
+func (tl Timeline) Between(from, to time.Time) Timeline {
+	var between Timeline
+	for _, entry := range tl {
+		if !entry.Time.Before(from) && !entry.Time.After(to) {
+			between = append(between, entry)
+		}
+	}
+	return between
+}
+
+// Len returns the number of entries.
+func (tl Timeline) Len() int {
+	return len(tl)
+}
+
+// Occurred reports whether an event with the given name ever executed.
+func (tl Timeline) Occurred(name string) bool {
+	_, ok := tl.Nth(name, 1)
+	return ok
+}
+
+// Nth returns the nth execution of the named event, counting from 1, and
+// false if it executed fewer than n times.
+func (tl Timeline) Nth(name string, n int) (TimelineEntry, bool) {
+	if n < 1 {
+		return TimelineEntry{}, false
+	}
+	for _, entry := range tl {
+		if entry.Name != name {
+			continue
+		}
+		n--
+		if n == 0 {
+			return entry, true
+		}
+	}
+	return TimelineEntry{}, false
+}
+
+// GapBetween returns the first gap reported by GapsBetween, and false if
+// `to` never ran after `from`.
+func (tl Timeline) GapBetween(from, to string) (time.Duration, bool) {
+	gaps := tl.GapsBetween(from, to)
+	if len(gaps) == 0 {
+		return 0, false
+	}
+	return gaps[0], true
+}
+
+// GapsBetween returns the virtual time from `from` to `to` for every
+// execution of `to` that has an execution of `from` before it, measured from
+// the most recent one. Each `from` is used at most once, so repeated cycles
+// such as monthly dunning yield one gap per cycle. If from and to are the same
+// name, the gaps are those between consecutive executions.
+func (tl Timeline) GapsBetween(from, to string) []time.Duration {
+	var gaps []time.Duration
+	var last *TimelineEntry
+	for i, entry := range tl {
+		if entry.Name == to && last != nil {
+			gaps = append(gaps, entry.Time.Sub(last.Time))
+			last = nil
+		}
+		if entry.Name == from {
+			last = &tl[i]
+		}
+	}
+	return gaps
+}
+
+// String lists the entries one per line, for failure messages.
+func (tl Timeline) String() string {
+	var b strings.Builder
+	for _, entry := range tl {
+		fmt.Fprintf(&b, "%s  %-12s %s\n", entry.Time.Format(logTimeFormat), entry.Partition, entry.Name)
+	}
+	return b.String()
+}
+
+// TimelineRecorder is a Diagnostic that keeps every executed event, so tests
+// can query what happened instead of comparing lists of names.
+type TimelineRecorder struct {
+	mu      sync.Mutex
+	entries Timeline
+}
+
+// NewTimelineRecorder returns an empty TimelineRecorder.
+func NewTimelineRecorder() *TimelineRecorder {
+	return &TimelineRecorder{}
+}
+
+func (r *TimelineRecorder) OnAdvanceStart(id string, start, target time.Time) {}
+func (r *TimelineRecorder) OnAdvanceFinish(id string, current time.Time)      {}
+func (r *TimelineRecorder) OnEventCreated(id string, eventName string, eventTime time.Time, currentTime time.Time) {
+}
+
+func (r *TimelineRecorder) OnEventExecute(id string, eventName string, t time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries = append(r.entries, TimelineEntry{Partition: id, Name: eventName, Time: t})
+}
+
+// Timeline returns a copy of the events executed in a partition so far.
+func (r *TimelineRecorder) Timeline(partitionID string) Timeline {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var timeline Timeline
+	for _, entry := range r.entries {
+		if entry.Partition == partitionID {
+			timeline = append(timeline, entry)
+		}
+	}
+	return timeline
+}
+
+// All returns a copy of the events executed in every partition so far, in
+// the order they executed.
+func (r *TimelineRecorder) All() Timeline {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append(Timeline(nil), r.entries...)
+}
@@ -0,0 +1,59 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aaryansingh-dev/hybrid-logical-time-go/internal/clock"
+)
+
+func TestTimeline_Queries(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	recorder := NewTimelineRecorder()
+	eng := NewEngine(recorder)
+	eng.RegisterPartition("tl", clock.NewTestClock(start))
+	eng.RegisterPartition("other", clock.NewTestClock(start))
+
+	// Parent at 0h and 3h, each followed by a Child an hour later
+	scheduleChain(eng, "tl", start)
+	scheduleChain(eng, "tl", start.Add(3*time.Hour))
+	scheduleChain(eng, "other", start)
+	for _, id := range []string{"tl", "other"} {
+		if err := eng.Advance(context.Background(), id, start.Add(6*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	timeline := recorder.Timeline("tl")
+	if timeline.Len() != 4 || recorder.All().Len() != 6 {
+		t.Fatalf("Expected 4 events in tl and 6 overall, got\n%s", recorder.All())
+	}
+	if got := timeline.Named("Child").Between(start, start.Add(2*time.Hour)).Len(); got != 1 {
+		t.Errorf("Expected 1 Child in the first two hours, got %d", got)
+	}
+	if second, ok := timeline.Nth("Child", 2); !ok || !second.Time.Equal(start.Add(4*time.Hour)) {
+		t.Errorf("Expected the second Child at 04:00, got %+v", second)
+	}
+	if _, ok := timeline.Nth("Child", 3); ok {
+		t.Error("Expected no third Child")
+	}
+	if gap, ok := timeline.GapBetween("Parent", "Child"); !ok || gap != time.Hour {
+		t.Errorf("Expected Child an hour after Parent, got %s", gap)
+	}
+	if gaps := timeline.GapsBetween("Parent", "Child"); len(gaps) != 2 || gaps[0] != time.Hour || gaps[1] != time.Hour {
+		t.Errorf("Expected a one hour gap for each Parent and Child pair, got %v", gaps)
+	}
+	if gaps := timeline.GapsBetween("Child", "Parent"); len(gaps) != 1 || gaps[0] != 2*time.Hour {
+		t.Errorf("Expected the second Parent two hours after the first Child, got %v", gaps)
+	}
+	if gaps := timeline.GapsBetween("Parent", "Parent"); len(gaps) != 1 || gaps[0] != 3*time.Hour {
+		t.Errorf("Expected the Parents three hours apart, got %v", gaps)
+	}
+	if _, ok := timeline.GapBetween("Child", "Missing"); ok {
+		t.Error("Expected no gap to an event that never ran")
+	}
+	if !timeline.Occurred("Parent") || timeline.Occurred("Missing") {
+		t.Error("Occurred gave the wrong answer")
+	}
+}
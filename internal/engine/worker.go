@@ -0,0 +1,235 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/aaryansingh-dev/hybrid-logical-time-go/internal/clock"
+)
+
+// Ticker is the tick source that drives the real-time worker. It mirrors the
+// part of *time.Ticker the worker needs, so tests can deliver ticks by hand
+// instead of sleeping.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// TickerFactory creates a Ticker firing at the given interval.
+type TickerFactory func(interval time.Duration) Ticker
+
+// timeTicker adapts *time.Ticker to the Ticker interface.
+type timeTicker struct {
+	*time.Ticker
+}
+
+func (t timeTicker) C() <-chan time.Time { return t.Ticker.C }
+
+func newTimeTicker(interval time.Duration) Ticker {
+	return timeTicker{time.NewTicker(interval)}
+}
+
+// StopPolicy decides what happens to due SYSTEM events when the worker stops.
+type StopPolicy int
+
+const (
+	// StopAbandon stops as soon as the currently executing event returns.
+	// Events that were due but not yet executed stay queued for the next start.
+	StopAbandon StopPolicy = iota
+
+	// StopDrain executes every event that is due at the moment of stopping
+	// before the worker exits. Events due in the future stay queued.
+	StopDrain
+)
+
+// WorkerConfig configures a RealTimeWorker. Only Interval is required.
+type WorkerConfig struct {
+	Interval   time.Duration
+	StopPolicy StopPolicy
+
+	// Ticker creates the tick source. Defaults to time.NewTicker.
+	Ticker TickerFactory
+
+	// Clock decides which SYSTEM events are due. Defaults to the wall-clock.
+	Clock clock.TimeProvider
+}
+
+// ErrWorkerRunning is returned by Start when the worker is already running.
+var ErrWorkerRunning = errors.New("real-time worker already running")
+
+// RealTimeWorker processes the SYSTEM partition in the background.
+// A worker can be stopped and started again any number of times; pending
+// SYSTEM events survive a restart because they live in the engine, not the worker.
+type RealTimeWorker struct {
+	engine *Engine
+	config WorkerConfig
+
+	mu   sync.Mutex
+	stop chan struct{} // closed to ask the running goroutine to exit
+	done chan struct{} // closed by the goroutine once it has exited
+}
+
+// NewRealTimeWorker creates a stopped worker for the engine's SYSTEM partition.
+func (engine *Engine) NewRealTimeWorker(config WorkerConfig) *RealTimeWorker {
+	if config.Ticker == nil {
+		config.Ticker = newTimeTicker
+	}
+	if config.Clock == nil {
+		config.Clock = clock.NewRealTimeProvider()
+	}
+	return &RealTimeWorker{engine: engine, config: config}
+}
+
+// StartRealTimeWorker launches a background goroutine that processes the SYSTEM partition.
+// It polls at the specified interval and executes events that have reached wall-clock time.
+// Future events generated by execution are automatically re-scheduled via the engine.
+// The returned worker can be used to stop and restart the goroutine.
+func (engine *Engine) StartRealTimeWorker(interval time.Duration) *RealTimeWorker {
+	worker := engine.NewRealTimeWorker(WorkerConfig{Interval: interval})
+	worker.Start()
+	return worker
+}
+
+// Start launches the worker goroutine. It returns ErrWorkerRunning if the
+// worker was already started and has not been stopped since.
+func (worker *RealTimeWorker) Start() error {
+	worker.mu.Lock()
+	defer worker.mu.Unlock()
+
+	if worker.running() {
+		return ErrWorkerRunning
+	}
+
+	worker.stop = make(chan struct{})
+	worker.done = make(chan struct{})
+	ticker := worker.config.Ticker(worker.config.Interval)
+
+	go worker.loop(ticker, worker.stop, worker.done)
+	return nil
+}
+
+// Stop asks the worker to exit and waits for it to do so, applying the
+// configured StopPolicy. If ctx ends first, Stop returns ctx.Err() and the
+// worker finishes stopping in the background. Stopping a worker that is not
+// running is a no-op.
+func (worker *RealTimeWorker) Stop(ctx context.Context) error {
+	worker.mu.Lock()
+	if !worker.running() {
+		worker.mu.Unlock()
+		return nil
+	}
+	select {
+	case <-worker.stop:
+		// another Stop already asked, just wait alongside it
+	default:
+		close(worker.stop)
+	}
+	done := worker.done
+	worker.mu.Unlock()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Running reports whether the worker goroutine is currently alive.
+func (worker *RealTimeWorker) Running() bool {
+	worker.mu.Lock()
+	defer worker.mu.Unlock()
+
+	return worker.running()
+}
+
+// running must be called with worker.mu held.
+func (worker *RealTimeWorker) running() bool {
+	if worker.done == nil {
+		return false
+	}
+	select {
+	case <-worker.done:
+		return false
+	default:
+		return true
+	}
+}
+
+func (worker *RealTimeWorker) loop(ticker Ticker, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	defer ticker.Stop()
+
+	// with StopAbandon a stop request interrupts a tick between events,
+	// with StopDrain the tick always runs to completion
+	var interrupt <-chan struct{}
+	if worker.config.StopPolicy == StopAbandon {
+		interrupt = stop
+	}
+
+	for {
+		select {
+		case <-stop:
+			if worker.config.StopPolicy == StopDrain {
+				worker.runDue(nil)
+			}
+			return
+		case <-ticker.C():
+			worker.runDue(interrupt)
+		}
+	}
+}
+
+// runDue executes every SYSTEM event that has reached the worker's clock.
+// If interrupt is closed, it returns before starting the next event.
+func (worker *RealTimeWorker) runDue(interrupt <-chan struct{}) {
+	engine := worker.engine
+	realTime := worker.config.Clock
+	now := realTime.Now()
+
+	executed := 0
+	defer func() {
+		engine.observe(EngineEvent{Kind: EngineWorkerTick, PartitionID: "SYSTEM", VirtualTime: now, Executed: executed})
+	}()
+
+	for {
+		select {
+		case <-interrupt:
+			return
+		default:
+		}
+
+		// a Cancel may remove the head at any time, so checking and popping
+		// must be one step
+		item, ok := engine.systemQueue.popDue(now)
+		if !ok || item.Event == nil {
+			return
+		}
+		id, event := item.ID, item.Event
+
+		// there is no caller to hand a rejected causal event back to; the
+		// rejection only affects that child, the rest of the tick carries on
+		worker.execute(id, event)
+		executed++
+	}
+}
+
+// execute runs a SYSTEM event. A panic must not take the worker's goroutine,
+// and with it the whole process, down: runEvent has already reported it, and
+// the event is retried or dead-lettered as if it had failed.
+func (worker *RealTimeWorker) execute(id EventID, event Event) {
+	engine := worker.engine
+	realTime := worker.config.Clock
+
+	defer func() {
+		if value := recover(); value != nil {
+			cause := &PanicError{Value: value}
+			if err := engine.retryOrDeadLetter("SYSTEM", id, event, realTime, cause); err != nil {
+				engine.observeFailed("SYSTEM", id, event, realTime, err)
+			}
+		}
+	}()
+	engine.execute("SYSTEM", id, event, realTime)
+}
@@ -0,0 +1,180 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aaryansingh-dev/hybrid-logical-time-go/internal/clock"
+)
+
+// manualTicker lets tests deliver worker ticks by hand
+type manualTicker struct {
+	ch      chan time.Time
+	stopped chan struct{}
+}
+
+func newManualTicker() *manualTicker {
+	return &manualTicker{ch: make(chan time.Time), stopped: make(chan struct{})}
+}
+
+func (m *manualTicker) C() <-chan time.Time { return m.ch }
+func (m *manualTicker) Stop()               { close(m.stopped) }
+
+// tick blocks until the worker has received the tick. Sending twice
+// guarantees the first tick has been fully processed.
+func (m *manualTicker) tick() {
+	m.ch <- time.Time{}
+}
+
+func newTestWorker(eng *Engine, policy StopPolicy, now time.Time) (*RealTimeWorker, *manualTicker) {
+	ticker := newManualTicker()
+	worker := eng.NewRealTimeWorker(WorkerConfig{
+		Interval:   time.Second,
+		StopPolicy: policy,
+		Ticker:     func(time.Duration) Ticker { return ticker },
+		Clock:      clock.NewTestClock(now),
+	})
+	return worker, ticker
+}
+
+func TestRealTimeWorker_ExecutesDueEventsOnTick(t *testing.T) {
+	diag := &MockDiagnostic{}
+	eng := NewEngine(diag)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	worker, ticker := newTestWorker(eng, StopAbandon, now)
+
+	eng.Schedule(&MockEvent{executionTime: now.Add(-time.Minute), name: "Due", clockID: "SYSTEM"})
+	eng.Schedule(&MockEvent{executionTime: now.Add(time.Minute), name: "Future", clockID: "SYSTEM"})
+
+	if err := worker.Start(); err != nil {
+		t.Fatal(err)
+	}
+	ticker.tick()
+	ticker.tick()
+
+	if err := worker.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	diag.mu.Lock()
+	defer diag.mu.Unlock()
+	if len(diag.eventsExecuted) != 1 || diag.eventsExecuted[0] != "Due" {
+		t.Errorf("Expected only the due event to run, got %v", diag.eventsExecuted)
+	}
+	if eng.systemQueue.Len() != 1 {
+		t.Errorf("Expected the future event to stay queued, got %d pending", eng.systemQueue.Len())
+	}
+}
+
+func TestRealTimeWorker_StopPolicies(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		policy      StopPolicy
+		wantPending int
+	}{
+		{"abandon leaves due events queued", StopAbandon, 2},
+		{"drain executes due events", StopDrain, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eng := NewEngine(nil)
+			worker, ticker := newTestWorker(eng, tt.policy, now)
+
+			eng.Schedule(&MockEvent{executionTime: now, name: "DueA", clockID: "SYSTEM"})
+			eng.Schedule(&MockEvent{executionTime: now, name: "DueB", clockID: "SYSTEM"})
+
+			worker.Start()
+			if err := worker.Stop(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := eng.systemQueue.Len(); got != tt.wantPending {
+				t.Errorf("Expected %d pending events after stop, got %d", tt.wantPending, got)
+			}
+			select {
+			case <-ticker.stopped:
+			default:
+				t.Error("Expected the ticker to be stopped")
+			}
+		})
+	}
+}
+
+func TestRealTimeWorker_Restart(t *testing.T) {
+	eng := NewEngine(nil)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	worker := eng.NewRealTimeWorker(WorkerConfig{
+		Interval: time.Second,
+		Ticker:   func(time.Duration) Ticker { return newManualTicker() },
+		Clock:    clock.NewTestClock(now),
+	})
+
+	if worker.Running() {
+		t.Fatal("Expected a new worker to be stopped")
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := worker.Start(); err != nil {
+			t.Fatalf("Start %d failed: %v", i, err)
+		}
+		if err := worker.Start(); err != ErrWorkerRunning {
+			t.Errorf("Expected ErrWorkerRunning on double start, got %v", err)
+		}
+		if !worker.Running() {
+			t.Error("Expected worker to be running after Start")
+		}
+		if err := worker.Stop(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if worker.Running() {
+			t.Error("Expected worker to be stopped after Stop")
+		}
+	}
+
+	// stopping an already stopped worker is a no-op
+	if err := worker.Stop(context.Background()); err != nil {
+		t.Errorf("Expected no error stopping a stopped worker, got %v", err)
+	}
+}
+
+func TestRealTimeWorker_SurvivesPanickingEvent(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	log := &engineLog{}
+	eng := NewEngine(log)
+	worker, ticker := newTestWorker(eng, StopAbandon, now)
+
+	boom, _ := eng.Schedule(&MockEvent{executionTime: now.Add(-2 * time.Minute), name: "Boom", clockID: "SYSTEM", onExecute: func(clock.TimeProvider) []Event {
+		panic("boom")
+	}})
+	eng.Schedule(&MockEvent{executionTime: now.Add(-time.Minute), name: "After", clockID: "SYSTEM"})
+	worker.Start()
+	ticker.tick()
+	ticker.tick()
+	if !worker.Running() {
+		t.Fatal("Expected the worker to survive the panic")
+	}
+	worker.Stop(context.Background())
+
+	var kinds []EngineEventKind
+	for _, event := range log.take() {
+		if event.EventName != "" {
+			kinds = append(kinds, event.Kind)
+		}
+	}
+	want := "[event_scheduled event_scheduled event_executed event_panicked event_dead_lettered event_executed]"
+	if fmt.Sprint(kinds) != want {
+		t.Errorf("Expected %s, got %v", want, kinds)
+	}
+
+	var panicked *PanicError
+	letters, _ := eng.DeadLetters("SYSTEM")
+	if len(letters) != 1 || letters[0].ID != boom || !errors.As(letters[0].Err, &panicked) || panicked.Value != "boom" {
+		t.Errorf("Expected the panicking event to be dead-lettered, got %+v", letters)
+	}
+}
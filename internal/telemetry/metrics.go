@@ -0,0 +1,192 @@
+package telemetry
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aaryansingh-dev/hybrid-logical-time-go/internal/engine"
+)
+
+// DefaultBuckets are the upper bounds, in seconds, of the advance duration
+// histogram. They match the Prometheus client defaults.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Metrics is a Diagnostic that counts what the engine does and serves it in
+// the Prometheus text exposition format.
+//
+// Executed and created events are counted per partition and event name. The
+// wall-clock duration of every Advance goes into a single histogram; it has no
+// partition label, since a service can hold one partition per customer.
+//
+// Pending queue depth and SYSTEM lag are gauges read from the engine when
+// the endpoint is scraped, so they are current even while nothing executes.
+// SYSTEM lag is how far wall-clock time is past the due time of the next
+// SYSTEM event, and zero when nothing is overdue.
+type Metrics struct {
+	// Buckets are the upper bounds of the advance duration histogram in
+	// seconds, in increasing order. Set them before the engine starts using
+	// the Metrics.
+	Buckets []float64
+
+	mu       sync.Mutex
+	executed map[series]uint64
+	created  map[series]uint64
+	started  map[string]time.Time // partition -> wall-clock start of its walk
+	counts   []uint64             // per bucket, not cumulative; the last one is +Inf
+	sum      float64
+	total    uint64
+}
+
+type series struct {
+	partition string
+	event     string
+}
+
+// NewMetrics returns a Metrics using DefaultBuckets.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		Buckets:  DefaultBuckets,
+		executed: make(map[series]uint64),
+		created:  make(map[series]uint64),
+		started:  make(map[string]time.Time),
+	}
+}
+
+func (m *Metrics) OnAdvanceStart(id string, start, target time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.started[id] = time.Now()
+}
+
+func (m *Metrics) OnEventExecute(id string, eventName string, t time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.executed[series{partition: id, event: eventName}]++
+}
+
+func (m *Metrics) OnEventCreated(id string, eventName string, eventTime time.Time, currentTime time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.created[series{partition: id, event: eventName}]++
+}
+
+func (m *Metrics) OnAdvanceFinish(id string, current time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	started, ok := m.started[id]
+	if !ok {
+		return
+	}
+	delete(m.started, id)
+	m.observe(time.Since(started).Seconds())
+}
+
+// observe adds one advance duration to the histogram. The caller must hold m.mu.
+func (m *Metrics) observe(seconds float64) {
+	if m.counts == nil {
+		m.counts = make([]uint64, len(m.Buckets)+1)
+	}
+	bucket := sort.SearchFloat64s(m.Buckets, seconds)
+	m.counts[bucket]++
+	m.sum += seconds
+	m.total++
+}
+
+// Handler serves the metrics for scraping, typically at /metrics. The gauges
+// are read from eng; if it is nil only the counters and the histogram are
+// served.
+func (m *Metrics) Handler(eng *engine.Engine) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		m.WriteText(w, eng)
+	})
+}
+
+// WriteText writes the metrics to w in the Prometheus text exposition format.
+// The gauges are read from eng unless it is nil.
+func (m *Metrics) WriteText(w io.Writer, eng *engine.Engine) error {
+	out := bufio.NewWriter(w)
+
+	m.mu.Lock()
+	writeCounter(out, "hlt_events_executed_total", "Events executed, by partition and event name.", m.executed)
+	writeCounter(out, "hlt_events_created_total", "Causal events created, by partition and event name.", m.created)
+	m.writeHistogram(out)
+	m.mu.Unlock()
+
+	if eng != nil {
+		writeGauges(out, eng.Statuses(), time.Now())
+	}
+	return out.Flush()
+}
+
+func writeCounter(out *bufio.Writer, name, help string, values map[series]uint64) {
+	keys := make([]series, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].partition != keys[j].partition {
+			return keys[i].partition < keys[j].partition
+		}
+		return keys[i].event < keys[j].event
+	})
+
+	fmt.Fprintf(out, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+	for _, key := range keys {
+		fmt.Fprintf(out, "%s{partition=\"%s\",event=\"%s\"} %d\n",
+			name, escapeLabel(key.partition), escapeLabel(key.event), values[key])
+	}
+}
+
+// writeHistogram writes the advance duration histogram. The caller must hold m.mu.
+func (m *Metrics) writeHistogram(out *bufio.Writer) {
+	const name = "hlt_advance_duration_seconds"
+	fmt.Fprintf(out, "# HELP %s Wall-clock duration of causal walks.\n# TYPE %s histogram\n", name, name)
+
+	var cumulative uint64
+	for i, bound := range m.Buckets {
+		if m.counts != nil {
+			cumulative += m.counts[i]
+		}
+		fmt.Fprintf(out, "%s_bucket{le=\"%s\"} %d\n", name, formatFloat(bound), cumulative)
+	}
+	fmt.Fprintf(out, "%s_bucket{le=\"+Inf\"} %d\n", name, m.total)
+	fmt.Fprintf(out, "%s_sum %s\n", name, formatFloat(m.sum))
+	fmt.Fprintf(out, "%s_count %d\n", name, m.total)
+}
+
+func writeGauges(out *bufio.Writer, statuses []engine.PartitionStatus, now time.Time) {
+	fmt.Fprintf(out, "# HELP hlt_pending_events Events waiting in a partition's queue.\n# TYPE hlt_pending_events gauge\n")
+	lag := 0.0
+	for _, status := range statuses {
+		fmt.Fprintf(out, "hlt_pending_events{partition=\"%s\"} %d\n", escapeLabel(status.ID), status.Pending)
+		if status.ID == "SYSTEM" && status.Pending > 0 && now.After(status.NextEventTime) {
+			lag = now.Sub(status.NextEventTime).Seconds()
+		}
+	}
+
+	fmt.Fprintf(out, "# HELP hlt_system_lag_seconds How far wall-clock time is past the due time of the next SYSTEM event.\n")
+	fmt.Fprintf(out, "# TYPE hlt_system_lag_seconds gauge\n")
+	fmt.Fprintf(out, "hlt_system_lag_seconds %s\n", formatFloat(lag))
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,82 @@
+package telemetry
+
+import (
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aaryansingh-dev/hybrid-logical-time-go/internal/clock"
+	"github.com/aaryansingh-dev/hybrid-logical-time-go/internal/engine"
+)
+
+// scrape fetches the handler's exposition and returns it as sample -> value
+func scrape(t *testing.T, metrics *Metrics, eng *engine.Engine) map[string]string {
+	t.Helper()
+
+	recorder := httptest.NewRecorder()
+	metrics.Handler(eng).ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := recorder.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Unexpected Content-Type %q", ct)
+	}
+
+	body, _ := io.ReadAll(recorder.Body)
+	samples := map[string]string{}
+	for _, line := range strings.Split(strings.TrimSpace(string(body)), "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.LastIndex(line, " ")
+		samples[line[:i]] = line[i+1:]
+	}
+	return samples
+}
+
+func TestMetrics_CountsEventsAndAdvances(t *testing.T) {
+	metrics := NewMetrics()
+	runInvoices(t, metrics)
+
+	samples := scrape(t, metrics, nil)
+	for sample, want := range map[string]string{
+		`hlt_events_executed_total{partition="cust_a",event="InvoiceCreated"}`: "3",
+		`hlt_events_executed_total{partition="cust_b",event="InvoiceCreated"}`: "3",
+		`hlt_events_created_total{partition="cust_a",event="InvoiceCreated"}`:  "2",
+		`hlt_advance_duration_seconds_bucket{le="+Inf"}`:                       "2",
+		`hlt_advance_duration_seconds_count`:                                   "2",
+	} {
+		if got := samples[sample]; got != want {
+			t.Errorf("%s = %q, want %q", sample, got, want)
+		}
+	}
+	if _, ok := samples[`hlt_pending_events{partition="SYSTEM"}`]; ok {
+		t.Error("Expected no gauges without an engine")
+	}
+}
+
+func TestMetrics_GaugesReadPendingAndSystemLag(t *testing.T) {
+	metrics := NewMetrics()
+	eng := engine.NewEngine(metrics)
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	eng.RegisterPartition("cust_a", clock.NewTestClock(start))
+	eng.Schedule(&invoiceEvent{at: start.AddDate(0, 1, 0), partition: "cust_a"})
+
+	samples := scrape(t, metrics, eng)
+	if got := samples[`hlt_pending_events{partition="cust_a"}`]; got != "1" {
+		t.Errorf("Expected 1 pending event in cust_a, got %q", got)
+	}
+	if got := samples["hlt_system_lag_seconds"]; got != "0" {
+		t.Errorf("Expected no lag with an empty SYSTEM queue, got %q", got)
+	}
+
+	// a SYSTEM event that was due a minute ago and has not run yet
+	eng.Schedule(&invoiceEvent{at: time.Now().Add(-time.Minute), partition: "SYSTEM"})
+	samples = scrape(t, metrics, eng)
+	if got := samples[`hlt_pending_events{partition="SYSTEM"}`]; got != "1" {
+		t.Errorf("Expected 1 pending SYSTEM event, got %q", got)
+	}
+	if lag, _ := strconv.ParseFloat(samples["hlt_system_lag_seconds"], 64); lag < 60 {
+		t.Errorf("Expected at least 60s of SYSTEM lag, got %v", lag)
+	}
+}
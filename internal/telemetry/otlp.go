@@ -0,0 +1,339 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultBatchSize is the number of finished spans after which a
+// SpanExporter sends them without waiting for Flush.
+const DefaultBatchSize = 512
+
+// DefaultExportTimeout bounds how long a SpanExporter waits for the collector
+// to accept an automatic batch.
+const DefaultExportTimeout = 10 * time.Second
+
+// SpanExporter is a Diagnostic that turns causal walks into OpenTelemetry
+// spans and sends them to a collector using OTLP/HTTP with JSON encoding.
+//
+// Every Advance becomes a span, and every event it executes a child span of
+// it. An executed event also carries a span link to the event that created
+// it, so the causal chain can be followed across Advance calls. Events are
+// matched to their creation by partition, name and time, like TraceWriter
+// does. Events run outside a walk, such as those of the SYSTEM worker, become
+// root spans of their own.
+//
+// Span timestamps are wall-clock times, so simulated flows line up with the
+// application spans they trigger; the virtual time is kept as an attribute.
+// An event span ends when the partition's next hook fires, since events have
+// no completion hook of their own.
+//
+// Spans are sent in batches of BatchSize once an Advance finishes, and
+// whatever is left by Flush. Automatic batches are sent in the background, so
+// a slow or unreachable collector never holds up a walk, and are abandoned
+// after ExportTimeout. Their errors are returned by the next Flush, which
+// waits for them first.
+type SpanExporter struct {
+	// Endpoint is the collector's traces URL, such as
+	// http://localhost:4318/v1/traces.
+	Endpoint string
+	// Client sends the requests; http.DefaultClient is used if nil.
+	Client *http.Client
+	// ServiceName is reported as the service.name resource attribute.
+	ServiceName string
+	// BatchSize is the number of finished spans that triggers an export.
+	BatchSize int
+	// ExportTimeout bounds every automatic export; zero means
+	// DefaultExportTimeout.
+	ExportTimeout time.Duration
+
+	inflight sync.WaitGroup // automatic exports not yet done
+	mu       sync.Mutex
+	finished []otlpSpan
+	advances map[string]*otlpSpan   // partition -> open Advance span
+	events   map[string]*otlpSpan   // partition -> open event span
+	creators map[flowKey][]otlpLink // created but not yet executed events -> creator span
+	err      error                  // first automatic export failure
+}
+
+// NewSpanExporter returns an exporter sending to the given OTLP/HTTP traces
+// endpoint.
+func NewSpanExporter(endpoint string) *SpanExporter {
+	return &SpanExporter{
+		Endpoint:      endpoint,
+		ServiceName:   "hlt",
+		BatchSize:     DefaultBatchSize,
+		ExportTimeout: DefaultExportTimeout,
+		advances:      make(map[string]*otlpSpan),
+		events:        make(map[string]*otlpSpan),
+		creators:      make(map[flowKey][]otlpLink),
+	}
+}
+
+// The types below mirror the OTLP JSON encoding of ExportTraceServiceRequest.
+// See https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID      string          `json:"traceId"`
+	SpanID       string          `json:"spanId"`
+	ParentSpanID string          `json:"parentSpanId,omitempty"`
+	Name         string          `json:"name"`
+	Kind         int             `json:"kind"`
+	Start        string          `json:"startTimeUnixNano"`
+	End          string          `json:"endTimeUnixNano"`
+	Attributes   []otlpAttribute `json:"attributes,omitempty"`
+	Links        []otlpLink      `json:"links,omitempty"`
+}
+
+type otlpLink struct {
+	TraceID string `json:"traceId"`
+	SpanID  string `json:"spanId"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+// spanKindInternal is SPAN_KIND_INTERNAL.
+const spanKindInternal = 1
+
+func attribute(key, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpValue{StringValue: value}}
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func newID(bytes int) string {
+	id := make([]byte, bytes)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+func (e *SpanExporter) OnAdvanceStart(id string, start, target time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.endEvent(id)
+	e.advances[id] = &otlpSpan{
+		TraceID: newID(16),
+		SpanID:  newID(8),
+		Name:    "Advance " + id,
+		Kind:    spanKindInternal,
+		Start:   unixNano(time.Now()),
+		Attributes: []otlpAttribute{
+			attribute("hlt.partition_id", id),
+			attribute("hlt.virtual_time.start", start.Format(time.RFC3339Nano)),
+			attribute("hlt.virtual_time.target", target.Format(time.RFC3339Nano)),
+		},
+	}
+}
+
+func (e *SpanExporter) OnEventExecute(id string, eventName string, t time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.endEvent(id)
+
+	span := &otlpSpan{
+		SpanID: newID(8),
+		Name:   eventName,
+		Kind:   spanKindInternal,
+		Start:  unixNano(time.Now()),
+		Attributes: []otlpAttribute{
+			attribute("hlt.partition_id", id),
+			attribute("hlt.virtual_time", t.Format(time.RFC3339Nano)),
+			attribute("hlt.event_name", eventName),
+		},
+	}
+	if advance, ok := e.advances[id]; ok {
+		span.TraceID = advance.TraceID
+		span.ParentSpanID = advance.SpanID
+	} else {
+		span.TraceID = newID(16)
+	}
+
+	key := flowKey{partition: id, name: eventName, at: t.UTC()}
+	if links := e.creators[key]; len(links) > 0 {
+		span.Links = []otlpLink{links[0]}
+		if len(links) == 1 {
+			delete(e.creators, key)
+		} else {
+			e.creators[key] = links[1:]
+		}
+	}
+	e.events[id] = span
+}
+
+func (e *SpanExporter) OnEventCreated(id string, eventName string, eventTime time.Time, currentTime time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	creator, ok := e.events[id]
+	if !ok {
+		return
+	}
+	key := flowKey{partition: id, name: eventName, at: eventTime.UTC()}
+	e.creators[key] = append(e.creators[key], otlpLink{TraceID: creator.TraceID, SpanID: creator.SpanID})
+}
+
+func (e *SpanExporter) OnAdvanceFinish(id string, current time.Time) {
+	e.mu.Lock()
+	e.endEvent(id)
+	if advance, ok := e.advances[id]; ok {
+		advance.End = unixNano(time.Now())
+		advance.Attributes = append(advance.Attributes, attribute("hlt.virtual_time.end", current.Format(time.RFC3339Nano)))
+		e.finished = append(e.finished, *advance)
+		delete(e.advances, id)
+	}
+
+	var batch []otlpSpan
+	if len(e.finished) >= e.BatchSize {
+		batch, e.finished = e.finished, nil
+	}
+	e.mu.Unlock()
+
+	if batch != nil {
+		// the partition's walk slot is still held, so the hook must not wait
+		// for the collector
+		e.inflight.Add(1)
+		go e.exportInBackground(batch)
+	}
+}
+
+// exportInBackground sends an automatic batch and keeps the first failure
+// for Flush.
+func (e *SpanExporter) exportInBackground(batch []otlpSpan) {
+	defer e.inflight.Done()
+
+	timeout := e.ExportTimeout
+	if timeout <= 0 {
+		timeout = DefaultExportTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := e.export(ctx, batch); err != nil {
+		e.mu.Lock()
+		if e.err == nil {
+			e.err = err
+		}
+		e.mu.Unlock()
+	}
+}
+
+// endEvent closes the partition's open event span. The caller must hold e.mu.
+func (e *SpanExporter) endEvent(id string) {
+	span, ok := e.events[id]
+	if !ok {
+		return
+	}
+	span.End = unixNano(time.Now())
+	e.finished = append(e.finished, *span)
+	delete(e.events, id)
+}
+
+// Flush sends every finished span. Spans of walks still in progress are sent
+// by a later Flush. It first waits for automatic exports still in flight, and
+// also reports an earlier failed one.
+func (e *SpanExporter) Flush(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		e.inflight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return fmt.Errorf("export spans: %w", ctx.Err())
+	}
+
+	e.mu.Lock()
+	// SYSTEM events have no walk to close them, so they are finished here
+	for id := range e.events {
+		if _, walking := e.advances[id]; !walking {
+			e.endEvent(id)
+		}
+	}
+	batch := e.finished
+	e.finished = nil
+	previous := e.err
+	e.err = nil
+	e.mu.Unlock()
+
+	var err error
+	if len(batch) > 0 {
+		err = e.export(ctx, batch)
+	}
+	return errors.Join(previous, err)
+}
+
+func (e *SpanExporter) export(ctx context.Context, spans []otlpSpan) error {
+	body, err := json.Marshal(otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: []otlpAttribute{attribute("service.name", e.ServiceName)}},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: "hlt/engine"}, Spans: spans}},
+	}}})
+	if err != nil {
+		return fmt.Errorf("export spans: %w", err)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, e.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("export spans: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	client := e.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return fmt.Errorf("export spans: %w", err)
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, response.Body)
+
+	if response.StatusCode/100 != 2 {
+		return fmt.Errorf("export spans: collector returned %s", response.Status)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// collector is a stand-in OTLP/HTTP receiver keeping every span it is sent
+type collector struct {
+	mu    sync.Mutex
+	spans []otlpSpan
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" {
+		http.Error(w, "unexpected request", http.StatusBadRequest)
+		return
+	}
+	var request otlpRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, resource := range request.ResourceSpans {
+		for _, scope := range resource.ScopeSpans {
+			c.spans = append(c.spans, scope.Spans...)
+		}
+	}
+}
+
+func TestSpanExporter_ExportsAdvanceAndEventSpans(t *testing.T) {
+	receiver := &collector{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	exporter := NewSpanExporter(server.URL + "/v1/traces")
+	runInvoices(t, exporter)
+	if err := exporter.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	byID := map[string]otlpSpan{}
+	var advances, events []otlpSpan
+	for _, span := range receiver.spans {
+		byID[span.SpanID] = span
+		if span.ParentSpanID == "" {
+			advances = append(advances, span)
+		} else {
+			events = append(events, span)
+		}
+	}
+	if len(advances) != 2 || len(events) != 6 {
+		t.Fatalf("Expected 2 advance spans and 6 event spans, got %d and %d", len(advances), len(events))
+	}
+
+	linked := 0
+	for _, span := range events {
+		parent, ok := byID[span.ParentSpanID]
+		if !ok || parent.TraceID != span.TraceID {
+			t.Errorf("Event span %s is not a child of its advance", span.Name)
+		}
+		if attributeValue(span, "hlt.partition_id") != attributeValue(parent, "hlt.partition_id") {
+			t.Errorf("Event span %s has the wrong partition", span.Name)
+		}
+		if attributeValue(span, "hlt.virtual_time") == "" || attributeValue(span, "hlt.event_name") != "InvoiceCreated" {
+			t.Errorf("Event span %s is missing attributes: %+v", span.Name, span.Attributes)
+		}
+		for _, link := range span.Links {
+			if _, ok := byID[link.SpanID]; !ok {
+				t.Errorf("Event span %s links to an unknown span", span.Name)
+			}
+			linked++
+		}
+	}
+	if linked != 4 {
+		t.Errorf("Expected 4 causal links, got %d", linked)
+	}
+}
+
+func TestSpanExporter_ReportsCollectorErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	exporter := NewSpanExporter(server.URL)
+	exporter.BatchSize = 1 // export from within the walk
+	runInvoices(t, exporter)
+
+	if err := exporter.Flush(context.Background()); err == nil {
+		t.Error("Expected the failed export to be reported by Flush")
+	}
+}
+
+func TestSpanExporter_HungCollectorDoesNotBlockAdvance(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	exporter := NewSpanExporter(server.URL)
+	exporter.BatchSize = 1
+	exporter.ExportTimeout = 50 * time.Millisecond
+
+	done := make(chan struct{})
+	go func() {
+		runInvoices(t, exporter)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Advance waited for the collector")
+	}
+
+	if err := exporter.Flush(context.Background()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the timed out export to be reported by Flush, got %v", err)
+	}
+}
+
+func attributeValue(span otlpSpan, key string) string {
+	for _, attribute := range span.Attributes {
+		if attribute.Key == key {
+			return attribute.Value.StringValue
+		}
+	}
+	return ""
+}
@@ -0,0 +1,214 @@
+// Package telemetry holds engine.Diagnostic implementations that export what
+// the engine does to external tools.
+package telemetry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// traceEvent is one entry of the Chrome Trace Event Format.
+// See https://docs.google.com/document/d/1CvAClvFfyA5R-PhYUmn5OOQtYMH4h6I0nSsKchNAySU
+type traceEvent struct {
+	Name  string         `json:"name"`
+	Cat   string         `json:"cat,omitempty"`
+	Phase string         `json:"ph"`
+	TS    int64          `json:"ts"` // microseconds
+	Dur   int64          `json:"dur,omitempty"`
+	PID   int            `json:"pid"`
+	TID   int            `json:"tid"`
+	ID    uint64         `json:"id,omitempty"`
+	BP    string         `json:"bp,omitempty"`
+	Scope string         `json:"s,omitempty"`
+	Args  map[string]any `json:"args,omitempty"`
+}
+
+// tracePID is the single process all partition tracks belong to.
+const tracePID = 1
+
+// DefaultSliceDuration is how wide an executed event is drawn. Events are
+// instantaneous in virtual time, but zero-width slices are hard to click on.
+const DefaultSliceDuration = time.Second
+
+// TraceWriter is a Diagnostic that writes the causal walks of every partition
+// as Chrome Trace Event Format JSON, which Perfetto (ui.perfetto.dev) and
+// chrome://tracing can open.
+//
+// Each partition is a track and each executed event a slice placed at its
+// virtual time. A flow arrow links the event that created another one to the
+// slice where the created event later executes. Events are matched to their
+// creation by partition, name and time, so arrows are only drawn for events
+// scheduled into the creator's own partition.
+//
+// Events are streamed to the writer as they happen; Close terminates the JSON
+// array and must be called once the simulation is done.
+type TraceWriter struct {
+	// SliceDuration is the drawn width of an executed event. Set it before the
+	// engine starts using the writer.
+	SliceDuration time.Duration
+
+	mu      sync.Mutex
+	w       io.Writer
+	written int
+	err     error
+
+	tracks   map[string]int       // partition -> tid
+	flows    map[flowKey][]uint64 // created but not yet executed events -> flow ids
+	nextFlow uint64
+}
+
+type flowKey struct {
+	partition string
+	name      string
+	at        time.Time
+}
+
+// NewTraceWriter returns a TraceWriter streaming to w.
+func NewTraceWriter(w io.Writer) *TraceWriter {
+	return &TraceWriter{
+		SliceDuration: DefaultSliceDuration,
+		w:             w,
+		tracks:        make(map[string]int),
+		flows:         make(map[flowKey][]uint64),
+	}
+}
+
+func (t *TraceWriter) OnAdvanceStart(id string, start, target time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.emit(traceEvent{
+		Name:  "Advance",
+		Cat:   "advance",
+		Phase: "i",
+		Scope: "t",
+		TS:    start.UnixMicro(),
+		PID:   tracePID,
+		TID:   t.track(id),
+		Args:  map[string]any{"from": start, "target": target},
+	})
+}
+
+func (t *TraceWriter) OnEventExecute(id string, eventName string, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tid := t.track(id)
+	t.emit(traceEvent{
+		Name:  eventName,
+		Cat:   "event",
+		Phase: "X",
+		TS:    at.UnixMicro(),
+		Dur:   t.SliceDuration.Microseconds(),
+		PID:   tracePID,
+		TID:   tid,
+		Args:  map[string]any{"partition": id, "virtual_time": at},
+	})
+
+	// finish the flow from whichever event created this one
+	key := flowKey{partition: id, name: eventName, at: at.UTC()}
+	if ids := t.flows[key]; len(ids) > 0 {
+		t.emit(traceEvent{
+			Name:  "caused",
+			Cat:   "causality",
+			Phase: "f",
+			BP:    "e",
+			TS:    at.UnixMicro(),
+			PID:   tracePID,
+			TID:   tid,
+			ID:    ids[0],
+		})
+		if len(ids) == 1 {
+			delete(t.flows, key)
+		} else {
+			t.flows[key] = ids[1:]
+		}
+	}
+}
+
+func (t *TraceWriter) OnEventCreated(id string, eventName string, eventTime time.Time, currentTime time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.nextFlow++
+	t.emit(traceEvent{
+		Name:  "caused",
+		Cat:   "causality",
+		Phase: "s",
+		TS:    currentTime.UnixMicro(),
+		PID:   tracePID,
+		TID:   t.track(id),
+		ID:    t.nextFlow,
+		Args:  map[string]any{"created": eventName, "scheduled_for": eventTime},
+	})
+
+	key := flowKey{partition: id, name: eventName, at: eventTime.UTC()}
+	t.flows[key] = append(t.flows[key], t.nextFlow)
+}
+
+func (t *TraceWriter) OnAdvanceFinish(id string, current time.Time) {}
+
+// Close ends the JSON document and returns the first write error, if any.
+// It does not close the underlying writer.
+func (t *TraceWriter) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.written == 0 {
+		t.write("[")
+	}
+	t.write("\n]\n")
+	return t.err
+}
+
+// track returns the tid of a partition, naming the track on first use.
+// The caller must hold t.mu.
+func (t *TraceWriter) track(partitionID string) int {
+	if tid, ok := t.tracks[partitionID]; ok {
+		return tid
+	}
+	tid := len(t.tracks) + 1
+	t.tracks[partitionID] = tid
+	t.emit(traceEvent{
+		Name:  "thread_name",
+		Phase: "M",
+		PID:   tracePID,
+		TID:   tid,
+		Args:  map[string]any{"name": partitionID},
+	})
+	return tid
+}
+
+// emit appends one event to the JSON array. The caller must hold t.mu.
+func (t *TraceWriter) emit(event traceEvent) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		t.fail(fmt.Errorf("encode trace event: %w", err))
+		return
+	}
+	if t.written == 0 {
+		t.write("[\n")
+	} else {
+		t.write(",\n")
+	}
+	t.write(string(data))
+	t.written++
+}
+
+func (t *TraceWriter) write(s string) {
+	if t.err != nil {
+		return
+	}
+	if _, err := io.WriteString(t.w, s); err != nil {
+		t.fail(fmt.Errorf("write trace: %w", err))
+	}
+}
+
+func (t *TraceWriter) fail(err error) {
+	if t.err == nil {
+		t.err = err
+	}
+}
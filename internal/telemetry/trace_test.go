@@ -0,0 +1,95 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aaryansingh-dev/hybrid-logical-time-go/internal/clock"
+	"github.com/aaryansingh-dev/hybrid-logical-time-go/internal/engine"
+)
+
+// invoiceEvent invoices every month until it reaches the given count
+type invoiceEvent struct {
+	at        time.Time
+	partition string
+	left      int
+}
+
+func (e *invoiceEvent) Time() time.Time { return e.at }
+func (e *invoiceEvent) Name() string    { return "InvoiceCreated" }
+func (e *invoiceEvent) ClockID() string { return e.partition }
+func (e *invoiceEvent) Execute(tp clock.TimeProvider) []engine.Event {
+	if e.left == 0 {
+		return nil
+	}
+	return []engine.Event{&invoiceEvent{at: tp.Now().AddDate(0, 1, 0), partition: e.partition, left: e.left - 1}}
+}
+
+// runInvoices advances two customers through a few invoices each.
+func runInvoices(t *testing.T, diag engine.Diagnostic) {
+	t.Helper()
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	eng := engine.NewEngine(diag)
+	for _, id := range []string{"cust_a", "cust_b"} {
+		eng.RegisterPartition(id, clock.NewTestClock(start))
+		eng.Schedule(&invoiceEvent{at: start, partition: id, left: 2})
+		if err := eng.Advance(context.Background(), id, start.AddDate(1, 0, 0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestTraceWriter_WritesTracksSlicesAndFlows(t *testing.T) {
+	var buf bytes.Buffer
+	trace := NewTraceWriter(&buf)
+	runInvoices(t, trace)
+	if err := trace.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var events []traceEvent
+	if err := json.Unmarshal(buf.Bytes(), &events); err != nil {
+		t.Fatalf("Trace is not valid JSON: %v\n%s", err, buf.String())
+	}
+
+	phases := map[string]int{}
+	tracks := map[int]string{}
+	flowStarts := map[uint64]bool{}
+	for _, event := range events {
+		phases[event.Phase]++
+		if event.Phase == "M" {
+			tracks[event.TID] = event.Args["name"].(string)
+		}
+		if event.Phase == "s" {
+			flowStarts[event.ID] = true
+		}
+		if event.Phase == "f" && !flowStarts[event.ID] {
+			t.Errorf("Flow %d finishes before it starts", event.ID)
+		}
+	}
+
+	if len(tracks) != 2 || tracks[1] != "cust_a" || tracks[2] != "cust_b" {
+		t.Errorf("Expected one track per partition, got %v", tracks)
+	}
+	if phases["X"] != 6 {
+		t.Errorf("Expected 6 executed slices, got %d", phases["X"])
+	}
+	if phases["s"] != 4 || phases["f"] != 4 {
+		t.Errorf("Expected 4 flow arrows, got %d starts and %d finishes", phases["s"], phases["f"])
+	}
+}
+
+func TestTraceWriter_EmptyTraceIsValid(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewTraceWriter(&buf).Close(); err != nil {
+		t.Fatal(err)
+	}
+	var events []traceEvent
+	if err := json.Unmarshal(buf.Bytes(), &events); err != nil || len(events) != 0 {
+		t.Errorf("Expected an empty JSON array, got %q (%v)", buf.String(), err)
+	}
+}